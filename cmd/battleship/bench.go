@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"battleship-zk/internal/app"
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/zk"
+)
+
+// cmdBench compares the merkle hashes: constraint count, key setup and proving time
+func cmdBench() {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	cfg := configFlags(fs)
+	keysDir := fs.String("keys", "./bench-keys", "keys directory (keys are generated if missing)")
+	n := fs.Int("n", 5, "proofs per hash")
+	setLogger := logFlags(fs, cfg.Log)
+	_ = fs.Parse(os.Args[2:])
+	setLogger()
+	if *n < 1 { fatal(errors.New("--n must be at least 1")) }
+
+	b, err := game.GenerateBoard(game.NewSeededRand(1), game.StrategyUniform)
+	if err != nil { fatal(err) }
+
+	fmt.Printf("%-10s %12s %12s %12s %12s\n", "hash", "constraints", "compile", "setup", "prove(avg)")
+	for _, h := range []merkle.Hash{merkle.HashMiMC, merkle.HashPoseidon2} {
+		start := time.Now()
+		cs, err := zk.CompileShot(h)
+		if err != nil { fatal(err) }
+		compile := time.Since(start)
+
+		start = time.Now()
+		res, err := app.Commit(b, *keysDir, h)
+		if err != nil { fatal(err) }
+		setup := time.Since(start)
+
+		var prove time.Duration
+		for i := 0; i < *n; i++ {
+			idx := (i * 37) % 100
+			start = time.Now()
+			if _, err := app.Shoot(context.Background(), res.Secret, *keysDir, idx/10, idx%10); err != nil { fatal(err) }
+			prove += time.Since(start)
+		}
+
+		fmt.Printf("%-10s %12d %12s %12s %12s\n", h, cs.GetNbConstraints(),
+			compile.Round(time.Millisecond), setup.Round(time.Millisecond),
+			(prove / time.Duration(*n)).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"battleship-zk/internal/config"
+	"battleship-zk/internal/zk"
+)
+
+// configFlags loads the file named by --config (or $BATTLESHIP_CONFIG) and
+// the environment before the other flags are defined, so their defaults
+// come from it and a flag given on the command line still wins.
+func configFlags(fs *flag.FlagSet) config.Config {
+	path := configPath(os.Args[2:])
+	fs.String("config", path, "config file, TOML (env "+config.EnvFile+")")
+	cfg, err := config.Load(path)
+	if err != nil { fatal(err) }
+	return cfg
+}
+
+// vkPath is cfg.VK, or the vk of game.hash in cfg.Keys
+func vkPath(cfg config.Config) string {
+	if cfg.VK != "" { return cfg.VK }
+	return zk.VKPath(cfg.Keys, cfg.Hash())
+}
+
+// configPath finds --config in args ahead of fs.Parse
+func configPath(args []string) string {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" { break }
+		name, val, hasVal := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "config" { continue }
+		if hasVal { return val }
+		if i+1 < len(args) { return args[i+1] }
+	}
+	return os.Getenv(config.EnvFile)
+}
+
+func cmdConfig() {
+	if len(os.Args) < 3 || os.Args[2] != "print" {
+		fmt.Println("usage: battleship config print [--config battleship.toml] [--env]")
+		os.Exit(2)
+	}
+	os.Args = append(os.Args[:2], os.Args[3:]...)
+	fs := flag.NewFlagSet("config print", flag.ExitOnError)
+	cfg := configFlags(fs)
+	env := fs.Bool("env", false, "list the environment variables that override the file instead")
+	_ = fs.Parse(os.Args[2:])
+
+	if *env {
+		for _, name := range cfg.Env() {
+			fmt.Println(name)
+		}
+		return
+	}
+	b, err := cfg.TOML()
+	if err != nil { fatal(err) }
+	fmt.Println("# effective configuration: defaults, then the config file, then " + config.EnvPrefix + "* variables")
+	os.Stdout.Write(b)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a flag on the command line wins over the environment, the file and the
+// defaults; without it the flag's default is the loaded value
+func TestConfigFlagsPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "battleship.toml")
+	if err := os.WriteFile(path, []byte("secret = \"file.json\"\n[serve]\naddr = \":1\"\n[serve.limits]\nburst = 2\nrate = 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BATTLESHIP_SERVE_LIMITS_RATE", "3")
+	t.Setenv("BATTLESHIP_SERVE_LIMITS_BURST", "4")
+
+	args := os.Args
+	t.Cleanup(func() { os.Args = args })
+	os.Args = []string{"battleship", "serve", "--addr", ":2", "--config=" + path, "--burst", "5"}
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cfg := configFlags(fs)
+	addr := fs.String("addr", cfg.Serve.Addr, "")
+	keys := fs.String("keys", cfg.Keys, "")
+	secret := fs.String("secret", cfg.Secret, "")
+	rate := fs.Float64("rate", cfg.Serve.Limits.Rate, "")
+	burst := fs.Int("burst", cfg.Serve.Limits.Burst, "")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"keys from the defaults", *keys, "./keys"},
+		{"secret from the file", *secret, "file.json"},
+		{"rate from the env", *rate, 3.0},
+		{"addr from the flag", *addr, ":2"},
+		{"burst from the flag", *burst, 5},
+	}
+	for _, tc := range tests {
+		if tc.got != tc.want {
+			t.Errorf("%s: %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("BATTLESHIP_CONFIG", "env.toml")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "env.toml"},
+		{[]string{"--config", "a.toml"}, "a.toml"},
+		{[]string{"-config=b.toml", "--addr", ":1"}, "b.toml"},
+		{[]string{"--addr", ":1", "--config"}, "env.toml"},
+		{[]string{"--", "--config", "c.toml"}, "env.toml"},
+	}
+	for _, tc := range tests {
+		if got := configPath(tc.args); got != tc.want {
+			t.Errorf("configPath(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
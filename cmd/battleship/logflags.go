@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	gnarklog "github.com/consensys/gnark/logger"
+
+	"battleship-zk/internal/config"
+	"battleship-zk/internal/logging"
+)
+
+// logFlags adds --log-level and --log-format to fs, call the returned func
+// after fs.Parse to install the logger. Logs go to stderr, stdout is only
+// a command's result. gnark's own zerolog lines are only kept at debug.
+func logFlags(fs *flag.FlagSet, def config.Log) func() {
+	level := fs.String("log-level", def.Level, "debug, info, warn or error")
+	format := fs.String("log-format", def.Format, "text or json")
+	return func() {
+		l, err := logging.New(os.Stderr, *level, *format)
+		if err != nil { fatal(err) }
+		slog.SetDefault(l)
+		// the log package is only used by dependencies
+		slog.SetLogLoggerLevel(slog.LevelError)
+		if l.Enabled(context.Background(), slog.LevelDebug) {
+			gnarklog.SetOutput(os.Stderr)
+		} else {
+			gnarklog.Disable()
+		}
+	}
+}
+
+// fatal logs err and exits with status 1. It stands in for log.Fatal so
+// the error comes out in the command's --log-format.
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
+}
@@ -1,16 +1,27 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"log"
-	"math/big"
+	"log/slog"
+	"maps"
 	"os"
-	"crypto/rand"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"crypto/tls"
+	"net"
     "net/http"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"battleship-zk/internal/app"
 	"battleship-zk/internal/server"
 	"battleship-zk/internal/codec"
 	"battleship-zk/internal/game"
@@ -34,165 +45,440 @@ func main() {
 		cmdVerify()
 	case "serve":
         cmdServe() 
+	case "bench":
+		cmdBench()
+	case "export-verifier":
+		cmdExportVerifier()
+	case "export-calldata":
+		cmdExportCalldata()
+	case "tls-cert":
+		cmdTLSCert()
+	case "config":
+		cmdConfig()
+	case "play":
+		cmdPlay()
 	default:
 		usage()
 	}
 }
 
 func usage() {
-	fmt.Println(`Battleship-ZK CLI
+	fmt.Print(`Battleship-ZK CLI
 
 Commands:
-  init   --out board.json
-  commit --board board.json --secret secret.json --keys ./keys
-  shoot  --secret secret.json --keys ./keys --row R --col C --out proof.json
-  verify --vk ./keys/shot.vk --root ROOT_HEX --proof proof.json
+  init   --out board.json [--seed N] [--strategy uniform|edge-avoid|spread|anti-hunt]
+  commit --board board.json --secret secret.json --keys ./keys [--salt salt.json] [--encrypt] [--hash mimc|poseidon2]
+  shoot  --secret secret.json --keys ./keys --row R --col C --out proof.json [--proof-format points|compressed]
+  shoot  --board board.json --salt salt.json --keys ./keys --row R --col C --out proof.json
+  verify --vk ./keys/shot.vk --root ROOT_HEX --row R --col C --proof proof.json
+  serve  --addr :8080 --keys ./keys --secret secret.json [--encrypt] [--grpc-addr :9090] [--peer URL --public-url URL]
+         [--hash mimc|poseidon2] [--strategy uniform|edge-avoid|spread|anti-hunt]
+         [--state FILE] [--read-timeout 15s] [--write-timeout 2m] [--idle-timeout 60s] [--shutdown-timeout 30s]
+         [--tls-cert FILE --tls-key FILE | --tls-self-signed [--tls-name NAME] [--tls-hosts H1,H2]]
+         [--peer-ca FILE] [--public-url URL] [--cors-origins O1,O2] [--cors-methods ROUTE=M1,M2] [--cors-peer=false] [--cors-hosts H1,H2]
+         [--max-body 65536] [--rate 5] [--burst 20] [--prove-workers 1] [--prove-queue 4]
+  play   --peer URL [--server http://localhost:8080] [--board board.json] [--ca certs.pem]   (terminal game client)
+  bench  --keys ./bench-keys --n 5   (compare constraint count and proving time per hash)
+  export-verifier --vk ./keys/shot.vk --out Verifier.sol
+  export-calldata --proof proof.json --out calldata.json   (arguments for verifyProof)
+  tls-cert --cert tls.crt --key tls.key [--name NAME] [--hosts H1,H2] [--days 365]   (self-signed cert for serve)
+  config print [--env]   (the effective configuration as TOML, or the variables that override it)
+
+init, commit, shoot and verify take --json to print their result (and shoot its proof payload) as JSON.
+Encrypted secret files read the passphrase from $BATTLESHIP_PASSPHRASE or prompt for it.
+commit, shoot, verify, serve, bench, export-verifier, export-calldata and tls-cert log to stderr, see --log-level debug|info|warn|error
+and --log-format text|json.
+Every command takes --config battleship.toml (or $BATTLESHIP_CONFIG) for its defaults, BATTLESHIP_*
+variables override the file and flags override both, see "config print".
+
 `)
 }
 
 func cmdInit() {
 	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	cfg := configFlags(fs)
 	out := fs.String("out", "board.json", "output board file")
+	seed := fs.Uint64("seed", 0, "seed for a reproducible board (default: crypto/rand)")
+	strategy := fs.String("strategy", cfg.Game.Strategy, "placement strategy: uniform, edge-avoid, spread, anti-hunt")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
 	_ = fs.Parse(os.Args[2:])
 
-	b, err := game.GenerateRandomBoard()
-	if err != nil { log.Fatal(err) }
-	if err := saveJSON(*out, b); err != nil { log.Fatal(err) }
-	fmt.Println("✓ wrote", *out)
+	opts := app.InitOptions{Strategy: *strategy}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" { opts.Seed = seed }
+	})
+	b, err := app.InitBoard(opts)
+	if err != nil { fatal(err) }
+	if err := saveJSON(*out, b); err != nil { fatal(err) }
+	report(*asJSON, map[string]any{"out": *out, "board": b}, "✓ wrote "+*out)
 }
 
 func cmdCommit() {
 	fs := flag.NewFlagSet("commit", flag.ExitOnError)
+	cfg := configFlags(fs)
 	boardPath := fs.String("board", "board.json", "board file")
-	secretPath := fs.String("secret", "secret.json", "defender secret state")
-	keysDir := fs.String("keys", "./keys", "keys directory")
+	secretPath := fs.String("secret", cfg.Secret, "defender secret state, empty to only write --salt")
+	saltPath := fs.String("salt", "", "also write the salt and nonces to this file, shoot can prove from it and --board")
+	keysDir := fs.String("keys", cfg.Keys, "keys directory")
+	encrypt := fs.Bool("encrypt", cfg.Encrypt, "encrypt the secret and salt files with a passphrase (env "+passphraseEnv+" or prompt)")
+	hashName := fs.String("hash", cfg.Game.Hash, "merkle hash: mimc or poseidon2")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	setLogger := logFlags(fs, cfg.Log)
 	_ = fs.Parse(os.Args[2:])
+	setLogger()
 
-	var b game.Board
-	if err := loadJSON(*boardPath, &b); err != nil { log.Fatal(err) }
-	if err := b.Validate(); err != nil { log.Fatal(err) }
-
-	leafHash := func(v uint8) *big.Int { return merkle.HashLeafMiMC(v) }
-	zeroLeaf := leafHash(0)
-	t, err := merkle.BuildFixedTree(b.Flatten(), 128, zeroLeaf, merkle.HashNodeMiMC)
-	if err != nil { log.Fatal(err) }
-	treeRoot := t.Root()
+	if *secretPath == "" && *saltPath == "" { fatal(errors.New("nothing to write, give --secret or --salt")) }
+	h, err := merkle.ParseHash(*hashName)
+	if err != nil { fatal(err) }
 
-	saltBytes := make([]byte, 32)
-	if _, err := rand.Read(saltBytes); err != nil {
-		log.Fatal(err)
+	var pass []byte
+	if *encrypt {
+		if pass, err = readPassphrase(true); err != nil { fatal(err) }
 	}
-	salt := new(big.Int).SetBytes(saltBytes)
-
-	saltedRoot := merkle.HashNodeMiMC(salt, treeRoot)
-
-	fmt.Println("ROOT:", fmt.Sprintf("0x%x", saltedRoot))
-	// fmt.Println("SALT:", fmt.Sprintf("0x%x", salt))
-	// fmt.Println("Unsalted ROOT:", fmt.Sprintf("0x%x", treeRoot))
-
-	if err := zk.EnsureShotKeys(*keysDir); err != nil { log.Fatal(err) }
 
-	sec := codec.Secret{
-		Board:  b,
-		Tree:   t,
-		SaltHex: fmt.Sprintf("0x%x", salt),
+	var b game.Board
+	if err := loadJSON(*boardPath, &b); err != nil { fatal(err) }
+	res, err := app.Commit(b, *keysDir, h)
+	if err != nil { fatal(err) }
+
+	lines := []string{"ROOT: " + res.RootHex}
+	if *secretPath != "" {
+		if err := codec.SaveSecret(*secretPath, &res.Secret, pass); err != nil { fatal(err) }
+		lines = append(lines, "✓ wrote "+*secretPath)
+	}
+	if *saltPath != "" {
+		salts, err := res.Secret.Salts()
+		if err != nil { fatal(err) }
+		if err := codec.SaveSalts(*saltPath, salts, pass); err != nil { fatal(err) }
+		lines = append(lines, "✓ wrote "+*saltPath)
 	}
-	if err := saveJSON(*secretPath, &sec); err != nil { log.Fatal(err) }
-	fmt.Println("✓ wrote", *secretPath)
+	report(*asJSON, commitOutput{RootHex: res.RootHex, Hash: string(h), Board: *boardPath, Secret: *secretPath, Salt: *saltPath}, strings.Join(lines, "\n"))
+}
+
+type commitOutput struct {
+	RootHex string `json:"rootHex"`
+	Hash    string `json:"hash"`
+	Board   string `json:"board"`
+	Secret  string `json:"secret,omitempty"`
+	Salt    string `json:"salt,omitempty"`
 }
 
 func cmdShoot() {
 	fs := flag.NewFlagSet("shoot", flag.ExitOnError)
-	secretPath := fs.String("secret", "secret.json", "defender secret state")
-	keysDir := fs.String("keys", "./keys", "keys directory")
+	cfg := configFlags(fs)
+	secretPath := fs.String("secret", cfg.Secret, "defender secret state")
+	boardPath := fs.String("board", "", "prove from this board file and --salt instead of --secret")
+	saltPath := fs.String("salt", "", "salt file written by commit --salt")
+	keysDir := fs.String("keys", cfg.Keys, "keys directory")
 	row := fs.Int("row", 0, "row [0..9]")
 	col := fs.Int("col", 0, "col [0..9]")
-	out := fs.String("out", "proof.json", "proof output")
+	out := fs.String("out", "proof.json", "proof output, empty to only print it with --json")
+	format := fs.String("proof-format", string(codec.EncodingPoints), "proof encoding: points or compressed")
+	asJSON := fs.Bool("json", false, "print the result and the proof payload as JSON")
+	setLogger := logFlags(fs, cfg.Log)
 	_ = fs.Parse(os.Args[2:])
+	setLogger()
+
+	enc, err := codec.ParseProofEncoding(*format)
+	if err != nil { fatal(err) }
+	if *out == "" && !*asJSON { fatal(errors.New("--out is empty and --json is off, the proof would go nowhere")) }
+
+	var sec *codec.Secret
+	if *boardPath != "" || *saltPath != "" {
+		if *boardPath == "" || *saltPath == "" { fatal(errors.New("--board and --salt go together")) }
+		sec, err = openBoard(*boardPath, *saltPath)
+	} else {
+		sec, _, err = loadSecretFile(*secretPath)
+	}
+	if err != nil { fatal(err) }
 
-	var sec codec.Secret
-	if err := loadJSON(*secretPath, &sec); err != nil { log.Fatal(err) }
-	if *row < 0 || *row > 9 || *col < 0 || *col > 9 { log.Fatal("row/col out of range") }
-	idx := *row*10 + *col
-
-	bit := sec.Board.Cells[*row][*col]
-	path, dir, err := sec.Tree.Path(idx)
-	if err != nil { log.Fatal(err) }
-	if len(path) != zk.MerkleDepth || len(dir) != zk.MerkleDepth { log.Fatal("bad path length") }
+	res, err := app.Shoot(context.Background(), *sec, *keysDir, *row, *col)
+	if err != nil { fatal(err) }
+	res.Payload.Encoding = enc
 
-	if sec.SaltHex == "" {
-		log.Fatal("missing salt in secret.json")
+	if *out != "" {
+		if err := saveJSON(*out, &res.Payload); err != nil { fatal(err) }
 	}
+	report(*asJSON, shotOutput{Row: *row, Col: *col, Hit: res.Bit, Result: hitMiss(res.Bit), Out: *out, Payload: &res.Payload},
+		fmt.Sprintf("✓ wrote %s (result: %s)", *out, hitMiss(res.Bit)))
+}
+
+type shotOutput struct {
+	Row     int                     `json:"row"`
+	Col     int                     `json:"col"`
+	Hit     uint8                   `json:"hit"`
+	Result  string                  `json:"result"` // HIT or MISS
+	Out     string                  `json:"out,omitempty"`
+	Payload *codec.ShotProofPayload `json:"payload,omitempty"`
+}
 
-	if len(sec.SaltHex) < 3 || sec.SaltHex[:2] != "0x" {
-		log.Fatal("invalid salt hex format")
+// openBoard is the secret of a board file and the salt file of its commit
+func openBoard(boardPath, saltPath string) (*codec.Secret, error) {
+	var b game.Board
+	if err := loadJSON(boardPath, &b); err != nil { return nil, err }
+	enc, err := codec.IsEncryptedSecret(saltPath)
+	if err != nil { return nil, err }
+	var pass []byte
+	if enc {
+		if pass, err = readPassphrase(false); err != nil { return nil, err }
 	}
-	salt, ok := new(big.Int).SetString(sec.SaltHex[2:], 16)
-	if !ok {
-		log.Fatal("can't parse salt hex value")
+	salts, err := codec.LoadSalts(saltPath, pass)
+	if err != nil { return nil, err }
+	sec, err := salts.Open(b)
+	if errors.Is(err, codec.ErrRootMismatch) {
+		return nil, fmt.Errorf("%s is not the board %s was committed with", boardPath, saltPath)
 	}
-
-	treeRoot := sec.Tree.Root()
-
-	proof, pub, err := zk.ProveShot(*keysDir, bit, idx, path, dir, treeRoot, salt)
-	if err != nil { log.Fatal(err) }
-
-	payload := codec.ShotProofPayload{ Proof: proof, Public: pub }
-	if err := saveJSON(*out, &payload); err != nil { log.Fatal(err) }
-	fmt.Printf("✓ wrote %s (result: %s)\n", *out, map[uint8]string{0:"MISS",1:"HIT"}[bit])
+	return sec, err
 }
 
 func cmdVerify() {
 	fs := flag.NewFlagSet("verify", flag.ExitOnError)
-	vkPath := fs.String("vk", "./keys/shot.vk", "verifying key file")
+	cfg := configFlags(fs)
+	vkPath := fs.String("vk", vkPath(cfg), "verifying key file")
 	rootHex := fs.String("root", "", "root hex prefixed 0x")
 	proofPath := fs.String("proof", "proof.json", "proof payload json")
 	row := fs.Int("row", -1, "row [0..9]")
 	col := fs.Int("col", -1, "col [0..9]")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	setLogger := logFlags(fs, cfg.Log)
 	_ = fs.Parse(os.Args[2:])
+	setLogger()
 
-	if *rootHex == "" { log.Fatal("--root required") }
-	root, ok := new(big.Int).SetString((*rootHex)[2:], 16)
-	if !ok { log.Fatal("invalid root hex") }
+	if *rootHex == "" { fatal(errors.New("--root required")) }
+	root, err := codec.ParseFieldHex(*rootHex)
+	if err != nil { fatal(err) }
 
 	var payload codec.ShotProofPayload
-	if err := loadJSON(*proofPath, &payload); err != nil { log.Fatal(err) }
+	if err := loadJSON(*proofPath, &payload); err != nil { fatal(err) }
 
 	if *row < 0 || *row > 9 || *col < 0 || *col > 9 {
-		log.Fatal("row/col out of range")
+		fatal(errors.New("row/col out of range"))
 	}
 
 	if payload.Public.Row != uint8(*row) || payload.Public.Col != uint8(*col) {
-		log.Fatalf("Proof is for (%d, %d) but expected (%d, %d)", payload.Public.Row, payload.Public.Col, *row, *col)
+		fatal(fmt.Errorf("proof is for (%d, %d) but expected (%d, %d)", payload.Public.Row, payload.Public.Col, *row, *col))
+	}
+
+	res, err := app.VerifyWithRoot(*vkPath, root, payload)
+	if err == nil && !res.Valid { err = errors.New("invalid proof") }
+	if err != nil {
+		if *asJSON { report(true, verifyOutput{Row: *row, Col: *col, Error: err.Error()}, "") }
+		fatal(err)
 	}
+	report(*asJSON, verifyOutput{Valid: true, Row: *row, Col: *col, Hit: res.Hit, Result: hitMiss(res.Hit)}, hitMiss(res.Hit))
+}
+
+type verifyOutput struct {
+	Valid  bool   `json:"valid"`
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Hit    uint8  `json:"hit"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
 
-	res, err := zk.VerifyShot(*vkPath, payload.Proof, payload.Public, root)
-	if err != nil { log.Fatal(err) }
-	if !res { log.Fatal(errors.New("invalid proof")) }
-	if payload.Public.Hit != 0 && payload.Public.Hit != 1 { log.Fatal("invalid hit") }
-	fmt.Println(map[uint8]string{0:"MISS",1:"HIT"}[payload.Public.Hit])
+func hitMiss(bit uint8) string {
+	return map[uint8]string{0:"MISS",1:"HIT"}[bit]
+}
+
+// report prints v as JSON with --json, or the human readable text
+func report(asJSON bool, v any, text string) {
+	if !asJSON {
+		fmt.Println(text)
+		return
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil { fatal(err) }
 }
 
 func cmdServe() {
-    fs := flag.NewFlagSet("serve", flag.ExitOnError)
-    addr := fs.String("addr", ":8080", "listen address")
-    keys := fs.String("keys", "./keys", "keys directory")
-    secret := fs.String("secret", "secret.json", "defender secret file")
-    _ = fs.Parse(os.Args[2:])
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	cfg := configFlags(fs)
+	sc := cfg.Serve
+	addr := fs.String("addr", sc.Addr, "listen address")
+	keys := fs.String("keys", cfg.Keys, "keys directory")
+	secret := fs.String("secret", cfg.Secret, "defender secret file")
+	encrypt := fs.Bool("encrypt", cfg.Encrypt, "keep the secret file encrypted (env "+passphraseEnv+" or prompt)")
+	persistPlain := fs.Bool("persist-plaintext", sc.PersistPlaintext, "save boards committed through the API to --secret unencrypted; without it and --encrypt they are kept in memory only")
+	hashName := fs.String("hash", cfg.Game.Hash, "merkle hash of boards committed through the API when the request names none")
+	strategy := fs.String("strategy", cfg.Game.Strategy, "placement strategy of /v1/init when the request names none")
+	grpcAddr := fs.String("grpc-addr", sc.GRPCAddr, "also serve the gRPC API on this address (e.g. :9090)")
+	statePath := fs.String("state", sc.State, "game state file, saved on shutdown and restored on start (default: next to --secret)")
+	peerURL := fs.String("peer", sc.Peer, "base url of the opponent's server, registered at start")
+	readTimeout := fs.Duration("read-timeout", sc.Timeouts.Read, "max time to read a request")
+	writeTimeout := fs.Duration("write-timeout", sc.Timeouts.Write, "max time to answer a request, shoot includes proving")
+	idleTimeout := fs.Duration("idle-timeout", sc.Timeouts.Idle, "keep-alive idle timeout")
+	shutdownTimeout := fs.Duration("shutdown-timeout", sc.Timeouts.Shutdown, "wait this long for in-flight requests on SIGINT/SIGTERM")
+	tlsCert := fs.String("tls-cert", sc.TLS.Cert, "serve HTTPS (and gRPC over TLS) with this PEM cert")
+	tlsKey := fs.String("tls-key", sc.TLS.Key, "PEM key of --tls-cert")
+	selfSigned := fs.Bool("tls-self-signed", sc.TLS.SelfSigned, "generate a self-signed cert if --tls-cert doesn't exist yet (default files: next to --secret)")
+	tlsName := fs.String("tls-name", sc.TLS.Name, "player name put in a generated cert")
+	tlsHosts := fs.String("tls-hosts", strings.Join(sc.TLS.Hosts, ","), "comma separated dns names and ips of a generated cert")
+	peerCA := fs.String("peer-ca", sc.TLS.PeerCA, "PEM certs trusted for the opponent's server, turns on mTLS between the servers")
+	publicURL := fs.String("public-url", sc.PublicURL, "our base url as the opponent reaches it, required with --peer (default: taken from the request)")
+	corsOrigins := fs.String("cors-origins", strings.Join(sc.CORS.Origins, ","), "comma separated origins of other web pages allowed to use the API, * for any (default: only our own UI)")
+	corsPeer := fs.Bool("cors-peer", sc.CORS.Peer, "also allow the registered peer's origin to read /v1/status, /v1/openapi.json and /v1/ws")
+	corsHosts := fs.String("cors-hosts", strings.Join(sc.CORS.Hosts, ","), "comma separated host names our own UI is served under, besides localhost, ips, --addr's and --public-url's host")
+	corsMethods := maps.Clone(sc.CORS.Methods)
+	if corsMethods == nil { corsMethods = map[string][]string{} }
+	corsFlagged := map[string]bool{}
+	fs.Func("cors-methods", "ROUTE=METHOD,... methods allowed cross-origin on a route, repeatable (e.g. /v1/status=GET)", func(v string) error {
+		route, ms, ok := strings.Cut(v, "=")
+		if !ok || !strings.HasPrefix(route, "/") { return fmt.Errorf("want ROUTE=METHOD,..., got %q", v) }
+		if !corsFlagged[route] {
+			// the flag replaces the route's methods from the config file
+			corsMethods[route], corsFlagged[route] = nil, true
+		}
+		for _, m := range strings.Split(ms, ",") {
+			if m = strings.ToUpper(strings.TrimSpace(m)); m != "" { corsMethods[route] = append(corsMethods[route], m) }
+		}
+		return nil
+	})
+	maxBody := fs.Int64("max-body", sc.Limits.MaxBody, "max request body in bytes")
+	rate := fs.Float64("rate", sc.Limits.Rate, "requests per second per client ip, 0 for no limit")
+	burst := fs.Int("burst", sc.Limits.Burst, "requests a client may send at once")
+	proveWorkers := fs.Int("prove-workers", sc.Limits.ProveWorkers, "shots proved at the same time")
+	proveQueue := fs.Int("prove-queue", sc.Limits.ProveQueue, "shots waiting for a prover before 503 prover_busy")
+	setLogger := logFlags(fs, cfg.Log)
+	_ = fs.Parse(os.Args[2:])
+	setLogger()
+	// the turn order needs the id the peer knows us by, which a guess from
+	// --addr rarely is
+	if *peerURL != "" && *publicURL == "" { fatal(errors.New("--peer needs --public-url, our base url as the opponent reaches it")) }
 
-    if err := zk.EnsureShotKeys(*keys); err != nil {
-        log.Fatal(err)
-    }
+	h, err := merkle.ParseHash(*hashName)
+	if err != nil { fatal(err) }
+	st, err := game.ParseStrategy(*strategy)
+	if err != nil { fatal(err) }
+	if err := zk.EnsureShotKeys(*keys, h); err != nil { fatal(err) }
 
 	srv := server.New(*keys, *secret)
+	srv.Hash, srv.Strategy = h, st
+	srv.VKPath = zk.VKPath(*keys, h)
+	srv.Limits = &server.Limits{MaxBodyBytes: *maxBody, Rate: *rate, Burst: *burst, ProveWorkers: *proveWorkers, ProveQueue: *proveQueue}
+	srv.StatePath = *statePath
+	if srv.StatePath == "" {
+		srv.StatePath = strings.TrimSuffix(*secret, filepath.Ext(*secret)) + ".state.json"
+	}
+	enc, err := codec.IsEncryptedSecret(*secret)
+	if err != nil && !os.IsNotExist(err) { fatal(err) }
+	if *encrypt || enc {
+		if srv.Passphrase, err = readPassphrase(*encrypt && !enc); err != nil { fatal(err) }
+	}
+	srv.PersistPlaintext = *persistPlain
+	srv.PublicURL = *publicURL
+	srv.CORS = server.CORSPolicy{Methods: corsMethods, AllowPeer: *corsPeer}
+	for _, o := range strings.Split(*corsOrigins, ",") {
+		if o = strings.TrimSpace(o); o != "" { srv.CORS.Origins = append(srv.CORS.Origins, o) }
+	}
+	for _, h := range strings.Split(*corsHosts, ",") {
+		if h = strings.TrimSpace(h); h != "" { srv.CORS.Hosts = append(srv.CORS.Hosts, h) }
+	}
+	if h, _, err := net.SplitHostPort(*addr); err == nil && h != "" { srv.CORS.Hosts = append(srv.CORS.Hosts, h) }
+
+	tlsOpt := server.TLSOptions{CertFile: *tlsCert, KeyFile: *tlsKey, PeerCAFile: *peerCA}
+	if *selfSigned {
+		base := strings.TrimSuffix(*secret, filepath.Ext(*secret))
+		if tlsOpt.CertFile == "" { tlsOpt.CertFile = base + ".tls.crt" }
+		if tlsOpt.KeyFile == "" { tlsOpt.KeyFile = base + ".tls.key" }
+		if _, err := os.Stat(tlsOpt.CertFile); os.IsNotExist(err) {
+			if err := server.GenerateSelfSigned(tlsOpt.CertFile, tlsOpt.KeyFile, *tlsName, strings.Split(*tlsHosts, ","), 365*24*time.Hour); err != nil { fatal(err) }
+			slog.Info("generated self-signed cert", "cert", tlsOpt.CertFile, "name", *tlsName)
+		}
+	}
+	if (tlsOpt.CertFile == "") != (tlsOpt.KeyFile == "") { fatal(errors.New("--tls-cert and --tls-key go together")) }
+	var tlsConf *tls.Config
+	if tlsOpt.CertFile != "" {
+		if tlsConf, err = tlsOpt.ServerTLS(); err != nil { fatal(err) }
+		fp, err := server.CertFingerprint(tlsOpt.CertFile)
+		if err != nil { fatal(err) }
+		slog.Info("TLS cert", "cert", tlsOpt.CertFile, "sha256", fp)
+	}
+	if *peerCA != "" {
+		if tlsConf == nil { fatal(errors.New("--peer-ca needs --tls-cert/--tls-key or --tls-self-signed")) }
+		if srv.PeerHTTP, err = tlsOpt.PeerClient(); err != nil { fatal(err) }
+		srv.RequirePeerCert = true
+		slog.Info("mTLS with the peer", "peer_ca", *peerCA)
+	}
+
+	if err := srv.LoadSecret(); err != nil { fatal(err) }
+	if err := srv.LoadState(); err != nil { fatal(err) }
+	if *peerURL != "" {
+		if err := srv.SetPeer(context.Background(), *peerURL, *publicURL); err != nil { fatal(err) }
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// request contexts derive from this, cancelling it stops proving
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	errc := make(chan error, 2)
+	var gs *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil { fatal(err) }
+		opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(int(*maxBody)), grpc.ChainUnaryInterceptor(srv.UnaryLogger(), srv.UnaryLimiter())}
+		if tlsConf != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+		}
+		gs = grpc.NewServer(opts...)
+		srv.RegisterGRPC(gs)
+		slog.Info("serving gRPC", "addr", *grpcAddr)
+		go func() { errc <- gs.Serve(lis) }()
+	}
+
 	mux := http.NewServeMux()
 	srv.Routes(mux)
-	log.Println("Serving on", *addr)
-	log.Fatal(http.ListenAndServe(*addr, server.WithCORS(mux)))
-
+	hs := &http.Server{
+		Addr:              *addr,
+		Handler:           srv.WithRequestLog(srv.WithLimits(srv.WithCORS(mux))),
+		ReadHeaderTimeout: *readTimeout,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+		TLSConfig:         tlsConf,
+	}
+	if tlsConf != nil {
+		slog.Info("serving HTTPS", "addr", *addr)
+		go func() { errc <- hs.ListenAndServeTLS("", "") }()
+	} else {
+		slog.Info("serving HTTP", "addr", *addr)
+		go func() { errc <- hs.ListenAndServe() }()
+	}
 
-    log.Println("Serving on", *addr)
-    log.Fatal(http.ListenAndServe(*addr, mux))
+	// a listener that fails still shuts the other down and saves the
+	// state, then the command exits with its error
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	case serveErr = <-errc:
+		slog.Error("server stopped", "err", serveErr)
+	}
+	stop() // a second signal kills right away
+
+	sctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := hs.Shutdown(sctx); err != nil {
+		slog.Warn("http shutdown", "err", err)
+		cancelRequests()
+		_ = hs.Close()
+	}
+	if gs != nil {
+		done := make(chan struct{})
+		go func() { gs.GracefulStop(); close(done) }()
+		select {
+		case <-done:
+		case <-sctx.Done():
+			gs.Stop()
+		}
+	}
+	if err := srv.Close(); err != nil { fatal(fmt.Errorf("saving game state: %w", err)) }
+	slog.Info("game state saved", "path", srv.StatePath)
+	if serveErr != nil { fatal(fmt.Errorf("server stopped: %w", serveErr)) }
 }
 
 func saveJSON(path string, v any) error {
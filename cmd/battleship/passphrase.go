@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+
+	"battleship-zk/internal/codec"
+)
+
+// env var checked before prompting, handy for scripts and serve under systemd
+const passphraseEnv = "BATTLESHIP_PASSPHRASE"
+
+func readPassphrase(confirm bool) ([]byte, error) {
+	if v := os.Getenv(passphraseEnv); v != "" {
+		return []byte(v), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("passphrase required: set %s or run in a terminal", passphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		p2, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if string(p) != string(p2) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return p, nil
+}
+
+// loadSecretFile asks for the passphrase only when the file is encrypted
+func loadSecretFile(path string) (*codec.Secret, []byte, error) {
+	enc, err := codec.IsEncryptedSecret(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pass []byte
+	if enc {
+		if pass, err = readPassphrase(false); err != nil {
+			return nil, nil, err
+		}
+	}
+	sec, err := codec.LoadSecret(path, pass)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sec, pass, nil
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+// play is a terminal client for one player. It talks to our own server:
+// status and /v1/ws for what happens, /v1/fire for our shots (our server
+// gets the proof from the peer and verifies it). The peer's server is only
+// asked for its status, to show whether it's up.
+func cmdPlay() {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	cfg := configFlags(fs)
+	serverURL := fs.String("server", localURL(cfg.Serve.Addr, cfg.Serve.TLS.Cert != "" || cfg.Serve.TLS.SelfSigned), "base url of our own server")
+	peerURL := fs.String("peer", cfg.Serve.Peer, "base url of the opponent's server, registered with ours if it isn't yet")
+	boardPath := fs.String("board", "", "our board file, shown on the left and committed if the server has none yet")
+	caFile := fs.String("ca", "", "PEM certs to trust for https servers, e.g. the self-signed certs of both players")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "", "plain text, no colors")
+	_ = fs.Parse(os.Args[2:])
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) { fatal(errors.New("play needs a terminal")) }
+	hc, err := playHTTP(*caFile)
+	if err != nil { fatal(err) }
+
+	p := &player{
+		local:   client.New(*serverURL, client.WithHTTPClient(hc)),
+		peerURL: strings.TrimRight(*peerURL, "/"),
+		hash:    cfg.Game.Hash,
+		strat:   cfg.Game.Strategy,
+		taken:   map[[2]int]uint8{},
+		dealt:   map[[2]int]uint8{},
+		dirty:   make(chan struct{}, 1),
+		color:   !*noColor,
+	}
+	if p.peerURL != "" {
+		p.peer = client.New(p.peerURL, client.WithHTTPClient(hc), client.WithRetry(client.RetryPolicy{Attempts: 1}))
+	}
+	if *boardPath != "" {
+		var b api.Board
+		if err := loadJSON(*boardPath, &b); err != nil { fatal(err) }
+		p.own = &b
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := p.start(ctx); err != nil { fatal(err) }
+
+	if err := p.run(ctx); err != nil { fatal(err) }
+}
+
+// localURL is our server by its listen address
+func localURL(addr string, tls bool) string {
+	scheme := "http"
+	if tls { scheme = "https" }
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil { return scheme + "://" + addr }
+	if host == "" || host == "0.0.0.0" || host == "::" { host = "localhost" }
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+func playHTTP(caFile string) (*http.Client, error) {
+	hc := &http.Client{Timeout: 2 * time.Minute}
+	if caFile == "" { return hc, nil }
+	pem, err := os.ReadFile(caFile)
+	if err != nil { return nil, err }
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) { return nil, fmt.Errorf("%s: no PEM certs", caFile) }
+	// keep the default's proxy, dial and idle settings
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	hc.Transport = tr
+	return hc, nil
+}
+
+type player struct {
+	local, peer *client.Client
+	peerURL     string
+	hash, strat string
+	color       bool
+
+	mu       sync.Mutex
+	st       api.Status
+	own      *api.Board       // nil if we don't know our board
+	taken    map[[2]int]uint8 // shots at us, 1 is a hit
+	dealt    map[[2]int]uint8 // our verified shots
+	pending  *[2]int          // our shot being proved and verified
+	cur      [2]int           // cursor on the opponent's grid
+	msg      string
+	peerInfo string
+	live     bool // events connected, otherwise status is polled
+	lastN    int  // DefenseLast.N seen, to catch shots while polling
+
+	dirty chan struct{}
+}
+
+// start reads our server's status, commits --board if nothing is committed
+// and registers --peer
+func (p *player) start(ctx context.Context) error {
+	st, err := p.local.Status(ctx)
+	if err != nil { return fmt.Errorf("our server %s: %w", p.local.BaseURL, err) }
+	if st.MyRootHex == "" && p.own != nil {
+		res, err := p.local.Commit(ctx, api.CommitRequest{Board: *p.own, Hash: p.hash})
+		if err != nil { return fmt.Errorf("commit --board: %w", err) }
+		st.MyRootHex = res.RootHex
+	}
+	if p.peerURL != "" && (st.Peer == nil || strings.TrimRight(st.Peer.BaseURL, "/") != p.peerURL) {
+		if st, err = p.local.SetPeer(ctx, api.Peer{BaseURL: p.peerURL}); err != nil { return fmt.Errorf("registering the peer: %w", err) }
+	}
+	p.st = *st
+	p.lastN = st.DefenseLast.N
+	switch {
+	case st.MyRootHex == "":
+		p.msg = "no board committed yet, press c to commit a random one"
+	case st.Peer == nil:
+		p.msg = "no peer registered, start with --peer URL"
+	}
+	return nil
+}
+
+func (p *player) changed() {
+	select {
+	case p.dirty <- struct{}{}:
+	default:
+	}
+}
+
+func (p *player) say(format string, args ...any) {
+	p.mu.Lock()
+	p.msg = fmt.Sprintf(format, args...)
+	p.mu.Unlock()
+	p.changed()
+}
+
+// follow keeps p.st current from /v1/ws, and polls status while the push
+// channel can't be opened (e.g. an https server behind a private CA)
+func (p *player) follow(ctx context.Context) {
+	for ctx.Err() == nil {
+		if evs, err := p.local.Events(ctx); err == nil {
+			p.setLive(true)
+			for ev := range evs {
+				p.apply(ev)
+			}
+			p.setLive(false)
+		}
+		// poll until the next reconnect attempt
+		for i := 0; i < 5 && ctx.Err() == nil; i++ {
+			if st, err := p.local.Status(ctx); err == nil {
+				p.apply(api.Event{Type: api.EventStatus, Status: st})
+			} else {
+				p.say("our server: %v", err)
+			}
+			sleepCtx(ctx, time.Second)
+		}
+	}
+}
+
+func (p *player) setLive(v bool) {
+	p.mu.Lock()
+	p.live = v
+	p.mu.Unlock()
+	p.changed()
+}
+
+func (p *player) apply(ev api.Event) {
+	p.mu.Lock()
+	defer p.changed()
+	defer p.mu.Unlock()
+	if ev.Game != nil {
+		p.st.Game = *ev.Game
+	}
+	switch ev.Type {
+	case api.EventStatus:
+		if ev.Status == nil { return }
+		p.st = *ev.Status
+		if d := ev.Status.DefenseLast; d.N > p.lastN {
+			p.taken[[2]int{d.Row, d.Col}] = d.Bit
+			p.lastN = d.N
+		}
+	case api.EventTurn:
+		if ev.Turn != nil { p.st.Turn = *ev.Turn }
+	case api.EventDefense:
+		if ev.Shot != nil {
+			p.taken[[2]int{ev.Shot.Row, ev.Shot.Col}] = ev.Shot.Bit
+			p.lastN = max(p.lastN, ev.Shot.N)
+			p.msg = fmt.Sprintf("opponent fired at %d,%d: %s, proved", ev.Shot.Row, ev.Shot.Col, hitMiss(ev.Shot.Bit))
+		}
+	case api.EventVerified:
+		if ev.Shot != nil { p.dealt[[2]int{ev.Shot.Row, ev.Shot.Col}] = ev.Shot.Bit }
+	}
+}
+
+// watchPeer polls the peer's status for the header line
+func (p *player) watchPeer(ctx context.Context) {
+	if p.peer == nil { return }
+	for ctx.Err() == nil {
+		info := "unreachable"
+		cctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		if st, err := p.peer.Status(cctx); err == nil {
+			info = "online"
+			if st.MyRootHex == "" { info += ", no board yet" } else { info += ", committed" }
+		}
+		cancel()
+		p.mu.Lock()
+		p.peerInfo = info
+		p.mu.Unlock()
+		p.changed()
+		sleepCtx(ctx, 3*time.Second)
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// fire shoots at the cursor, our server does the round trip to the peer
+func (p *player) fire(ctx context.Context) {
+	p.mu.Lock()
+	cell := p.cur
+	switch {
+	case p.pending != nil:
+		p.mu.Unlock()
+		return
+	case p.st.Game.Over:
+		p.msg = "the game is over"
+	case !p.st.Turn.Ready || p.st.Turn.MyTurn != "me":
+		p.msg = "not your turn"
+	default:
+		if _, done := p.dealt[cell]; done {
+			p.msg = fmt.Sprintf("already fired at %d,%d", cell[0], cell[1])
+		} else {
+			p.pending = &cell
+			p.msg = fmt.Sprintf("firing at %d,%d, waiting for the proof...", cell[0], cell[1])
+		}
+	}
+	fire := p.pending != nil
+	p.mu.Unlock()
+	p.changed()
+	if !fire { return }
+
+	go func() {
+		res, err := p.local.FireAtPeer(ctx, cell[0], cell[1])
+		p.mu.Lock()
+		p.pending = nil
+		switch {
+		case err != nil:
+			p.msg = "shot failed: " + describe(err)
+		case !res.Valid:
+			p.msg = "the peer's proof did not verify"
+		default:
+			p.dealt[cell] = res.Hit
+			p.msg = fmt.Sprintf("%d,%d: %s, proof verified", cell[0], cell[1], hitMiss(res.Hit))
+		}
+		p.mu.Unlock()
+		p.changed()
+	}()
+}
+
+// commitRandom commits a fresh board when the server has none
+func (p *player) commitRandom(ctx context.Context) {
+	p.mu.Lock()
+	committed := p.st.MyRootHex != ""
+	p.mu.Unlock()
+	if committed {
+		p.say("a board is already committed")
+		return
+	}
+	p.say("committing a random board (the first time generates proving keys)...")
+	go func() {
+		b, err := p.local.Init(ctx, api.InitRequest{Strategy: p.strat})
+		if err != nil {
+			p.say("init failed: %s", describe(err))
+			return
+		}
+		res, err := p.local.Commit(ctx, api.CommitRequest{Board: *b, Hash: p.hash})
+		if err != nil {
+			p.say("commit failed: %s", describe(err))
+			return
+		}
+		p.mu.Lock()
+		p.own = b
+		p.st.MyRootHex = res.RootHex
+		p.msg = "committed " + short(res.RootHex)
+		p.mu.Unlock()
+		p.changed()
+	}()
+}
+
+func describe(err error) string {
+	var ce *client.Error
+	if errors.As(err, &ce) && ce.Body.Message != "" {
+		return ce.Body.Message
+	}
+	return err.Error()
+}
+
+func short(hex string) string {
+	if len(hex) > 14 { return hex[:10] + "…" + hex[len(hex)-4:] }
+	return hex
+}
+
+// run owns the terminal until q or ctrl-c
+func (p *player) run(ctx context.Context) error {
+	fd := int(os.Stdin.Fd())
+	old, err := term.MakeRaw(fd)
+	if err != nil { return err }
+	// alternate screen, hidden cursor, restored on the way out
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		_ = term.Restore(fd, old)
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go p.follow(ctx)
+	go p.watchPeer(ctx)
+
+	keys := make(chan key, 8)
+	go readKeys(os.Stdin, keys)
+
+	// the size is checked on a timer, SIGWINCH isn't portable
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	var lastW, lastH int
+	p.draw()
+	for {
+		select {
+		case k, ok := <-keys:
+			if !ok || k == keyQuit { return nil }
+			p.handle(ctx, k)
+		case <-p.dirty:
+		case <-tick.C:
+			w, h, _ := term.GetSize(int(os.Stdout.Fd()))
+			if w == lastW && h == lastH { continue }
+			lastW, lastH = w, h
+			fmt.Print("\x1b[2J")
+		}
+		p.draw()
+	}
+}
+
+func (p *player) handle(ctx context.Context, k key) {
+	p.mu.Lock()
+	move := func(dr, dc int) {
+		p.cur[0] = min(9, max(0, p.cur[0]+dr))
+		p.cur[1] = min(9, max(0, p.cur[1]+dc))
+	}
+	switch k {
+	case keyUp:
+		move(-1, 0)
+	case keyDown:
+		move(1, 0)
+	case keyLeft:
+		move(0, -1)
+	case keyRight:
+		move(0, 1)
+	}
+	p.mu.Unlock()
+	switch k {
+	case keyFire:
+		p.fire(ctx)
+	case keyCommit:
+		p.commitRandom(ctx)
+	case keyRefresh:
+		go func() {
+			if st, err := p.local.Status(ctx); err == nil {
+				p.apply(api.Event{Type: api.EventStatus, Status: st})
+			}
+		}()
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"battleship-zk/pkg/api"
+)
+
+type key int
+
+const (
+	keyNone key = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyFire
+	keyCommit
+	keyRefresh
+	keyQuit
+)
+
+// readKeys turns raw terminal input into keys: arrows, hjkl or wasd to
+// move, enter or space to fire. An ESC sequence from an arrow key cut off
+// by the end of a read is finished with the next one.
+func readKeys(r io.Reader, out chan<- key) {
+	defer close(out)
+	buf := make([]byte, 64)
+	var pend []byte // start of an ESC sequence
+	for {
+		n, err := r.Read(buf)
+		if err != nil { return }
+		in := append(pend, buf[:n]...)
+		pend = nil
+		for i := 0; i < len(in); i++ {
+			k := keyNone
+			switch c := in[i]; c {
+			case 0x1b:
+				// ESC [ A or ESC O A
+				rest := in[i+1:]
+				if len(rest) == 0 || (len(rest) == 1 && (rest[0] == '[' || rest[0] == 'O')) {
+					pend = append([]byte(nil), in[i:]...)
+					i = len(in)
+					continue
+				}
+				if rest[0] == '[' || rest[0] == 'O' {
+					switch rest[1] {
+					case 'A':
+						k = keyUp
+					case 'B':
+						k = keyDown
+					case 'C':
+						k = keyRight
+					case 'D':
+						k = keyLeft
+					}
+					i += 2
+				}
+			case 'k', 'w':
+				k = keyUp
+			case 'j', 's':
+				k = keyDown
+			case 'h', 'a':
+				k = keyLeft
+			case 'l', 'd':
+				k = keyRight
+			case '\r', '\n', ' ', 'f':
+				k = keyFire
+			case 'c':
+				k = keyCommit
+			case 'r':
+				k = keyRefresh
+			case 'q', 0x03, 0x04: // ctrl-c, ctrl-d
+				k = keyQuit
+			}
+			if k != keyNone {
+				out <- k
+			}
+		}
+	}
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRev    = "\x1b[7m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+func (p *player) paint(code, s string) string {
+	if !p.color || code == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// cell is one grid cell, two columns wide
+type cell struct {
+	ch    string
+	color string
+}
+
+// grids builds both boards. Ours shows ships if we know the board, and
+// where the opponent fired. Theirs shows our verified shots and the cursor.
+func (p *player) grids() (own, opp [10][10]cell) {
+	for r := 0; r < 10; r++ {
+		for c := 0; c < 10; c++ {
+			own[r][c] = cell{".", ansiDim}
+			if p.own != nil && p.own.Cells[r][c] == 1 {
+				own[r][c] = cell{"#", ansiCyan}
+			}
+			if bit, ok := p.taken[[2]int{r, c}]; ok {
+				own[r][c] = cell{"o", ""}
+				if bit == 1 {
+					own[r][c] = cell{"X", ansiRed}
+				}
+			}
+
+			opp[r][c] = cell{".", ansiDim}
+			if bit, ok := p.dealt[[2]int{r, c}]; ok {
+				opp[r][c] = cell{"o", ""}
+				if bit == 1 {
+					opp[r][c] = cell{"X", ansiRed}
+				}
+			}
+			if p.pending != nil && *p.pending == [2]int{r, c} {
+				opp[r][c] = cell{"?", ansiYellow}
+			}
+		}
+	}
+	return own, opp
+}
+
+func (p *player) gridLines(title string, g [10][10]cell, cursor *[2]int) []string {
+	lines := []string{p.paint(ansiBold, fmt.Sprintf("%-22s", title)), "   0 1 2 3 4 5 6 7 8 9"}
+	for r := 0; r < 10; r++ {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d ", r)
+		for c := 0; c < 10; c++ {
+			s := " " + p.paint(g[r][c].color, g[r][c].ch)
+			if cursor != nil && *cursor == [2]int{r, c} {
+				if p.color {
+					s = " " + ansiRev + g[r][c].ch + ansiReset
+				} else {
+					s = "[" + g[r][c].ch
+				}
+			}
+			b.WriteString(s)
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+func (p *player) turnLine() string {
+	g, t := p.st.Game, p.st.Turn
+	switch {
+	case g.Over && g.Winner == "me":
+		return p.paint(ansiGreen+ansiBold, "GAME OVER, you won")
+	case g.Over:
+		return p.paint(ansiRed+ansiBold, "GAME OVER, you lost")
+	case p.st.MyRootHex == "":
+		return p.paint(ansiYellow, "no board committed")
+	case !t.Ready:
+		return p.paint(ansiYellow, "waiting for the opponent's server")
+	case p.pending != nil:
+		return p.paint(ansiYellow, "your shot is being proved by the opponent")
+	case t.MyTurn == "me":
+		return p.paint(ansiGreen+ansiBold, "YOUR TURN, pick a cell and press enter")
+	}
+	return "opponent's turn"
+}
+
+// draw repaints the whole screen, lines end in \r\n because the terminal
+// is raw
+func (p *player) draw() {
+	w, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 {
+		w = 80
+	}
+
+	p.mu.Lock()
+	own, opp := p.grids()
+	cur := p.cur
+	left := p.gridLines("Your board", own, nil)
+	right := p.gridLines("Opponent", opp, &cur)
+
+	peer := "no peer"
+	if p.st.Peer != nil {
+		peer = p.st.Peer.BaseURL
+		if p.peerInfo != "" {
+			peer += " (" + p.peerInfo + ")"
+		}
+	}
+	push := "live"
+	if !p.live {
+		push = "polling"
+	}
+	lines := []string{
+		p.paint(ansiBold, "Battleship-ZK") + "  you: " + p.local.BaseURL + " [" + push + "]",
+		"peer: " + peer,
+		"",
+	}
+	if w >= 52 {
+		for i := range left {
+			lines = append(lines, left[i]+"    "+right[i])
+		}
+	} else {
+		lines = append(lines, left...)
+		lines = append(lines, "")
+		lines = append(lines, right...)
+	}
+	lines = append(lines,
+		"",
+		p.turnLine(),
+		fmt.Sprintf("hits dealt %d/%d  taken %d/%d", p.st.Game.HitsDealt, api.ShipCells, p.st.Game.HitsTaken, api.ShipCells),
+		p.msg,
+		"",
+		p.paint(ansiDim, "arrows/hjkl move  enter fire  c commit a random board  r refresh  q quit"),
+	)
+	p.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteString("\x1b[K\r\n")
+	}
+	b.WriteString("\x1b[J")
+	os.Stdout.WriteString(b.String())
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"battleship-zk/pkg/api"
+)
+
+// chunks is a reader returning one chunk per Read, like a terminal does
+type chunks []string
+
+func (c *chunks) Read(p []byte) (int, error) {
+	if len(*c) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, (*c)[0])
+	(*c)[0] = (*c)[0][n:]
+	if (*c)[0] == "" {
+		*c = (*c)[1:]
+	}
+	return n, nil
+}
+
+func TestReadKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   chunks
+		want []key
+	}{
+		{"letters", chunks{"hjkl", "wasd"}, []key{keyLeft, keyDown, keyUp, keyRight, keyUp, keyLeft, keyDown, keyRight}},
+		{"fire, commit, refresh, quit", chunks{"\r\n f", "cr", "q\x03\x04"}, []key{keyFire, keyFire, keyFire, keyFire, keyCommit, keyRefresh, keyQuit, keyQuit, keyQuit}},
+		{"arrows in one read", chunks{"\x1b[A\x1b[B\x1b[C\x1b[D"}, []key{keyUp, keyDown, keyRight, keyLeft}},
+		{"application mode arrows", chunks{"\x1bOA\x1bOD"}, []key{keyUp, keyLeft}},
+		{"split after ESC", chunks{"\x1b", "[A"}, []key{keyUp}},
+		{"split after [", chunks{"j\x1b[", "Dk"}, []key{keyDown, keyLeft, keyUp}},
+		{"split over three reads", chunks{"\x1b", "[", "C"}, []key{keyRight}},
+		{"lone ESC", chunks{"\x1b", "q"}, []key{keyQuit}},
+		{"unknown sequence", chunks{"\x1b[Zl"}, []key{keyRight}},
+		{"ESC at EOF", chunks{"h\x1b"}, []key{keyLeft}},
+		{"unbound keys", chunks{"xyz123"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := make(chan key, 64)
+			readKeys(&tc.in, out)
+			var got []key
+			for k := range out {
+				got = append(got, k)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("keys = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGridLines(t *testing.T) {
+	var own api.Board
+	own.Cells[0][0], own.Cells[0][1], own.Cells[5][5] = 1, 1, 1
+	p := &player{
+		own:     &own,
+		taken:   map[[2]int]uint8{{0, 1}: 1, {9, 9}: 0},
+		dealt:   map[[2]int]uint8{{2, 3}: 1, {2, 4}: 0},
+		pending: &[2]int{7, 0},
+	}
+	ownGrid, oppGrid := p.grids()
+	cur := [2]int{2, 4}
+
+	tests := []struct {
+		name   string
+		color  bool
+		lines  func() []string
+		want   map[int]string // line index to its text
+		header string
+	}{
+		{"own board", false, func() []string { return p.gridLines("Your board", ownGrid, nil) }, map[int]string{
+			2:  "0  # X . . . . . . . .",
+			7:  "5  . . . . . # . . . .",
+			11: "9  . . . . . . . . . o",
+		}, "Your board"},
+		{"opponent with cursor", false, func() []string { return p.gridLines("Opponent", oppGrid, &cur) }, map[int]string{
+			2: "0  . . . . . . . . . .",
+			4: "2  . . . X[o . . . . .",
+			9: "7  ? . . . . . . . . .",
+		}, "Opponent"},
+		{"color", true, func() []string { return p.gridLines("Opponent", oppGrid, &cur) }, map[int]string{
+			4: "2  " + ansiDim + "." + ansiReset + " " + ansiDim + "." + ansiReset + " " + ansiDim + "." + ansiReset + " " + ansiRed + "X" + ansiReset + " " + ansiRev + "o" + ansiReset +
+				strings.Repeat(" "+ansiDim+"."+ansiReset, 5),
+		}, ansiBold + "Opponent"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p.color = tc.color
+			lines := tc.lines()
+			if len(lines) != 12 {
+				t.Fatalf("%d lines, want a title, the column header and 10 rows", len(lines))
+			}
+			if !strings.HasPrefix(lines[0], tc.header) || lines[1] != "   0 1 2 3 4 5 6 7 8 9" {
+				t.Errorf("header %q %q", lines[0], lines[1])
+			}
+			for i, want := range tc.want {
+				if lines[i] != want {
+					t.Errorf("line %d = %q, want %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+
+	// without our board only the shots show
+	p.own = nil
+	ownGrid, _ = p.grids()
+	if c := ownGrid[0][0]; c.ch != "." {
+		t.Errorf("unknown board shows %q at 0,0", c.ch)
+	}
+	if c := ownGrid[0][1]; c.ch != "X" || c.color != ansiRed {
+		t.Errorf("hit at 0,1 drawn as %+v", c)
+	}
+}
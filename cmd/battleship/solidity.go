@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"battleship-zk/internal/codec"
+	"battleship-zk/internal/zk"
+)
+
+func cmdExportVerifier() {
+	fs := flag.NewFlagSet("export-verifier", flag.ExitOnError)
+	cfg := configFlags(fs)
+	vkPath := fs.String("vk", vkPath(cfg), "verifying key file")
+	out := fs.String("out", "Verifier.sol", "solidity output")
+	setLogger := logFlags(fs, cfg.Log)
+	_ = fs.Parse(os.Args[2:])
+	setLogger()
+
+	f, err := os.Create(*out)
+	if err != nil { fatal(err) }
+	defer f.Close()
+	if err := zk.ExportSolidityVerifier(*vkPath, f); err != nil { fatal(err) }
+	slog.Info("verifier written", "out", *out, "vk", *vkPath)
+}
+
+// calldata for Verifier.verifyProof / BattleshipSettlement.respond, as 0x hex
+type solidityCalldata struct {
+	Proof [8]string `json:"proof"`
+	Input [4]string `json:"input"`
+}
+
+func cmdExportCalldata() {
+	fs := flag.NewFlagSet("export-calldata", flag.ExitOnError)
+	cfg := configFlags(fs)
+	proofPath := fs.String("proof", "proof.json", "proof payload json")
+	out := fs.String("out", "calldata.json", "calldata output")
+	setLogger := logFlags(fs, cfg.Log)
+	_ = fs.Parse(os.Args[2:])
+	setLogger()
+
+	var payload codec.ShotProofPayload
+	if err := loadJSON(*proofPath, &payload); err != nil { fatal(err) }
+	proof, input, err := zk.SolidityCalldata(payload.Proof, payload.Public)
+	if err != nil { fatal(err) }
+
+	var cd solidityCalldata
+	for i, v := range proof { cd.Proof[i] = fmt.Sprintf("0x%064x", v) }
+	for i, v := range input { cd.Input[i] = fmt.Sprintf("0x%064x", v) }
+	if err := saveJSON(*out, &cd); err != nil { fatal(err) }
+	slog.Info("calldata written", "out", *out, "row", payload.Public.Row, "col", payload.Public.Col)
+}
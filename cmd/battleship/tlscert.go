@@ -0,0 +1,34 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"battleship-zk/internal/server"
+)
+
+// players swap the .crt files (never the keys) and pass the other one's as
+// serve --peer-ca
+func cmdTLSCert() {
+	fs := flag.NewFlagSet("tls-cert", flag.ExitOnError)
+	cfg := configFlags(fs)
+	tc := cfg.Serve.TLS
+	cert := fs.String("cert", cmp.Or(tc.Cert, "tls.crt"), "cert output")
+	key := fs.String("key", cmp.Or(tc.Key, "tls.key"), "key output")
+	name := fs.String("name", tc.Name, "player name in the cert")
+	hosts := fs.String("hosts", strings.Join(tc.Hosts, ","), "comma separated dns names and ips the cert is valid for")
+	days := fs.Int("days", 365, "validity in days")
+	setLogger := logFlags(fs, cfg.Log)
+	_ = fs.Parse(os.Args[2:])
+	setLogger()
+
+	if err := server.GenerateSelfSigned(*cert, *key, *name, strings.Split(*hosts, ","), time.Duration(*days)*24*time.Hour); err != nil { fatal(err) }
+	fp, err := server.CertFingerprint(*cert)
+	if err != nil { fatal(err) }
+	// compare the fingerprint with the peer out of band before trusting it
+	slog.Info("certificate written", "cert", *cert, "key", *key, "name", *name, "sha256", fp)
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// Verifier generated by `battleship export-verifier`, one per player since
+/// every player runs their own Groth16 setup.
+interface IShotVerifier {
+    /// input = (saltedRoot, hit, row, col), reverts if the proof is invalid
+    function verifyProof(uint256[8] calldata proof, uint256[4] calldata input) external view;
+}
+
+/// Reference settlement for a two player game. Both players deposit the same
+/// stake and their board commitment. Turns alternate: the attacker fires at a
+/// cell, the defender answers with a proof that the cell is a hit or a miss
+/// under their committed root. 17 hits win the pot, and a defender who stops
+/// answering forfeits after responseTimeout.
+contract BattleshipSettlement {
+    uint8 public constant SHIP_CELLS = 17;
+
+    struct Player {
+        address addr;
+        IShotVerifier verifier;
+        uint256 root;
+        uint8 hitsTaken;
+        uint256 shotsFired; // bitmap of cells this player already targeted
+    }
+
+    Player[2] public players;
+    uint256 public immutable stake;
+    uint256 public immutable responseTimeout;
+
+    uint8 public turn; // index of the player whose move it is
+    bool public pending; // a shot waits for the defender's proof
+    uint8 public pendingRow;
+    uint8 public pendingCol;
+    uint256 public pendingSince;
+
+    bool public over;
+    address public winner;
+
+    event Joined(address player, uint256 root);
+    event Fired(address attacker, uint8 row, uint8 col);
+    event Answered(address defender, uint8 row, uint8 col, bool hit);
+    event Won(address winner, uint256 pot);
+
+    error NotYourTurn();
+    error BadCell();
+    error AlreadyTargeted();
+    error NoPendingShot();
+    error ShotPending();
+    error GameOver();
+    error WrongStake();
+    error TooEarly();
+
+    constructor(uint256 stake_, uint256 responseTimeout_) {
+        stake = stake_;
+        responseTimeout = responseTimeout_;
+    }
+
+    /// First caller is player 0 and shoots first.
+    function join(IShotVerifier verifier, uint256 root) external payable {
+        if (msg.value != stake) revert WrongStake();
+        uint8 i = players[0].addr == address(0) ? 0 : 1;
+        require(players[i].addr == address(0), "game full");
+        require(i == 0 || players[0].addr != msg.sender, "already joined");
+        players[i] = Player(msg.sender, verifier, root, 0, 0);
+        emit Joined(msg.sender, root);
+    }
+
+    function fire(uint8 row, uint8 col) external {
+        _active();
+        if (pending) revert ShotPending();
+        if (msg.sender != players[turn].addr) revert NotYourTurn();
+        if (row > 9 || col > 9) revert BadCell();
+        uint256 bit = uint256(1) << (uint256(row) * 10 + col);
+        if (players[turn].shotsFired & bit != 0) revert AlreadyTargeted();
+        players[turn].shotsFired |= bit;
+
+        pending = true;
+        pendingRow = row;
+        pendingCol = col;
+        pendingSince = block.timestamp;
+        emit Fired(msg.sender, row, col);
+    }
+
+    /// Defender answers the pending shot. The public input is rebuilt here
+    /// from the stored root and coordinates, so only `hit` comes from the caller.
+    function respond(uint256[8] calldata proof, bool hit) external {
+        _active();
+        if (!pending) revert NoPendingShot();
+        uint8 d = 1 - turn;
+        Player storage def = players[d];
+        if (msg.sender != def.addr) revert NotYourTurn();
+
+        uint256[4] memory input = [def.root, hit ? uint256(1) : uint256(0), uint256(pendingRow), uint256(pendingCol)];
+        def.verifier.verifyProof(proof, input);
+
+        pending = false;
+        emit Answered(msg.sender, pendingRow, pendingCol, hit);
+        if (hit && ++def.hitsTaken == SHIP_CELLS) {
+            _settle(players[turn].addr);
+            return;
+        }
+        turn = d;
+    }
+
+    /// Attacker wins if the defender does not answer in time.
+    function claimTimeout() external {
+        _active();
+        if (!pending) revert NoPendingShot();
+        if (msg.sender != players[turn].addr) revert NotYourTurn();
+        if (block.timestamp < pendingSince + responseTimeout) revert TooEarly();
+        _settle(msg.sender);
+    }
+
+    function _active() private view {
+        if (over) revert GameOver();
+        require(players[1].addr != address(0), "waiting for opponent");
+    }
+
+    function _settle(address w) private {
+        over = true;
+        winner = w;
+        uint256 pot = address(this).balance;
+        emit Won(w, pot);
+        (bool ok, ) = payable(w).call{value: pot}("");
+        require(ok, "payout failed");
+    }
+}
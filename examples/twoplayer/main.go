@@ -0,0 +1,75 @@
+// Command twoplayer plays a full game between two in-process servers using
+// pkg/client. Each side fires at cells in order until one fleet is sunk.
+//
+//	go run ./examples/twoplayer --keys ./keys
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/consensys/gnark/logger"
+
+	"battleship-zk/internal/server"
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+func startServer(keysDir string) *httptest.Server {
+	mux := http.NewServeMux()
+	server.New(keysDir, "").Routes(mux)
+	return httptest.NewServer(mux)
+}
+
+func main() {
+	keys := flag.String("keys", "./keys", "keys directory, made on first commit")
+	flag.Parse()
+	logger.Disable()
+	ctx := context.Background()
+
+	srvA, srvB := startServer(*keys), startServer(*keys)
+	defer srvA.Close()
+	defer srvB.Close()
+	a, b := client.New(srvA.URL), client.New(srvB.URL)
+	names := map[*client.Client]string{a: "A", b: "B"}
+
+	for _, c := range []*client.Client{a, b} {
+		board, err := c.Init(ctx, api.InitRequest{})
+		if err != nil { log.Fatal(err) }
+		res, err := c.Commit(ctx, api.CommitRequest{Board: *board})
+		if err != nil { log.Fatal(err) }
+		fmt.Printf("%s committed %s\n", names[c], res.RootHex)
+	}
+
+	// once both know each other the turn order is decided
+	if _, err := a.SetPeer(ctx, api.Peer{BaseURL: srvB.URL}); err != nil { log.Fatal(err) }
+	if _, err := b.SetPeer(ctx, api.Peer{BaseURL: srvA.URL}); err != nil { log.Fatal(err) }
+
+	next := map[*client.Client]int{}
+	for {
+		st, err := a.Status(ctx)
+		if err != nil { log.Fatal(err) }
+		if st.Game.Over {
+			winner := "A"
+			if st.Game.Winner != "me" { winner = "B" }
+			fmt.Printf("game over, %s wins (A dealt %d hits, took %d)\n", winner, st.Game.HitsDealt, st.Game.HitsTaken)
+			return
+		}
+		if !st.Turn.Ready { log.Fatal("turn order not decided") }
+
+		attacker, defender := a, b
+		if st.Turn.MyTurn != "me" {
+			attacker, defender = b, a
+		}
+		cell := next[attacker]
+		next[attacker]++
+
+		res, err := client.Fire(ctx, attacker, defender, cell/10, cell%10)
+		if err != nil { log.Fatal(err) }
+		fmt.Printf("%s fires at (%d,%d): %s\n", names[attacker], cell/10, cell%10, map[uint8]string{0: "MISS", 1: "HIT"}[res.Hit])
+	}
+}
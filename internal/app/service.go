@@ -1,13 +1,17 @@
 package app
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
+	"log/slog"
 	"math/big"
 
 	"battleship-zk/internal/codec"
 	"battleship-zk/internal/game"
+	"battleship-zk/internal/logging"
 	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/metrics"
 	"battleship-zk/internal/zk"
 )
 
@@ -16,18 +20,36 @@ type CommitResult struct {
 	Secret  codec.Secret
 }
 
-func InitBoard() (game.Board, error) {
-	return game.GenerateRandomBoard()
+type InitOptions struct {
+	Strategy string  // placement strategy name, empty is uniform
+	Seed     *uint64 // nil means crypto/rand, set it for reproducible boards
 }
 
-func Commit(b game.Board, keysDir string) (*CommitResult, error) {
+func InitBoard(opts InitOptions) (game.Board, error) {
+	strategy, err := game.ParseStrategy(opts.Strategy)
+	if err != nil {
+		return game.Board{}, err
+	}
+	rng := game.NewCryptoRand()
+	if opts.Seed != nil {
+		rng = game.NewSeededRand(*opts.Seed)
+	}
+	return game.GenerateBoard(rng, strategy)
+}
+
+// Commit builds the board tree with hash h (empty means MiMC) and makes sure
+// the matching proving keys exist
+func Commit(b game.Board, keysDir string, h merkle.Hash) (*CommitResult, error) {
 	if err := b.Validate(); err != nil {
 		return nil, err
 	}
 
-	leafHash := func(v uint8) *big.Int { return merkle.HashLeafMiMC(v) }
-	zeroLeaf := leafHash(0)
-	t, err := merkle.BuildFixedTree(b.Flatten(), 128, zeroLeaf, merkle.HashNodeMiMC)
+	nonces, err := codec.NewBlindingNonces()
+	if err != nil {
+		return nil, err
+	}
+	h = h.Normalize()
+	t, err := codec.BuildBoardTree(b, nonces, h)
 	if err != nil {
 		return nil, err
 	}
@@ -39,10 +61,10 @@ func Commit(b game.Board, keysDir string) (*CommitResult, error) {
 		return nil, err
 	}
 	salt := new(big.Int).SetBytes(saltBytes)
-	saltedRoot := merkle.HashNodeMiMC(salt, treeRoot)
+	saltedRoot := h.Node(salt, treeRoot)
 	rootHex := fmt.Sprintf("0x%x", saltedRoot)
 
-	if err := zk.EnsureShotKeys(keysDir); err != nil {
+	if err := zk.EnsureShotKeys(keysDir, h); err != nil {
 		return nil, err
 	}
 
@@ -50,8 +72,11 @@ func Commit(b game.Board, keysDir string) (*CommitResult, error) {
 		Board:   b,
 		Tree:    t,
 		SaltHex: fmt.Sprintf("0x%x", salt),
+		Nonces:  nonces,
+		Hash:    h,
 	}
 
+	slog.Debug("board committed", "root", rootHex, "hash", h)
 	return &CommitResult{RootHex: rootHex, Secret: sec}, nil
 }
 
@@ -60,17 +85,26 @@ type ShootResult struct {
 	Bit     uint8
 }
 
-func Shoot(sec codec.Secret, keysDir string, row, col int) (*ShootResult, error) {
+// Shoot proves the answer to a shot at (row, col). It gives up with
+// ctx.Err() if ctx is done before proving starts.
+func Shoot(ctx context.Context, sec codec.Secret, keysDir string, row, col int) (*ShootResult, error) {
 	if row < 0 || row > 9 || col < 0 || col > 9 {
 		return nil, fmt.Errorf("row/col out of range")
 	}
-	if sec.SaltHex == "" || len(sec.SaltHex) < 3 || sec.SaltHex[:2] != "0x" {
-		return nil, fmt.Errorf("missing or invalid salt in secret")
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-
-	salt, ok := new(big.Int).SetString(sec.SaltHex[2:], 16)
-	if !ok {
-		return nil, fmt.Errorf("cannot parse salt hex")
+	salt, err := sec.Salt()
+	if err != nil {
+		return nil, err
+	}
+	if !sec.Blinded() {
+		return nil, fmt.Errorf("secret has no per-cell blinding (made by an older version), commit the board again")
+	}
+	if sec.Tree == nil {
+		if sec.Tree, err = codec.BuildBoardTree(sec.Board, sec.Nonces, sec.Hash); err != nil {
+			return nil, err
+		}
 	}
 	treeRoot := sec.Tree.Root()
 
@@ -84,10 +118,24 @@ func Shoot(sec codec.Secret, keysDir string, row, col int) (*ShootResult, error)
 		return nil, fmt.Errorf("bad path length")
 	}
 
-	proof, pub, err := zk.ProveShot(keysDir, bit, idx, path, dir, treeRoot, salt)
+	// cheap native check of what the circuit will assert, so a broken secret
+	// fails here instead of after a full Groth16 prove
+	saltedRoot, err := sec.SaltedRoot()
+	if err != nil {
+		return nil, err
+	}
+	h := sec.Hash.Normalize()
+	leaf := h.LeafBlinded(bit, sec.Nonces[idx])
+	if err := h.VerifySaltedPath(leaf, idx, path, dir, salt, saltedRoot); err != nil {
+		return nil, fmt.Errorf("secret is inconsistent at (%d,%d): %w", row, col, err)
+	}
+
+	logging.From(ctx).Debug("proving shot", "row", row, "col", col, "hash", sec.Hash.Normalize())
+	proof, pub, err := zk.ProveShot(ctx, keysDir, sec.Hash, bit, sec.Nonces[idx], idx, path, dir, treeRoot, salt)
 	if err != nil {
 		return nil, err
 	}
+	metrics.ProofsGenerated.WithLabelValues(metrics.HitMiss(bit)).Inc()
 
 	return &ShootResult{
 		Payload: codec.ShotProofPayload{Proof: proof, Public: pub},
@@ -111,8 +159,5 @@ func VerifyWithRoot(vkPath string, root *big.Int, payload codec.ShotProofPayload
 	if err != nil {
 		return nil, err
 	}
-	if payload.Public.Hit != 0 && payload.Public.Hit != 1 {
-		return nil, fmt.Errorf("invalid hit public output")
-	}
 	return &VerifyResult{Valid: res, Hit: payload.Public.Hit}, nil
 }
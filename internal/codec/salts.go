@@ -0,0 +1,158 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+)
+
+// SaltsVersion is the current salt file format
+const SaltsVersion = 1
+
+// Salts is the random part of a commitment, without the board: the salt of
+// the root and the per-cell nonces. Together with the board file it opens
+// the commitment, so keep it as private as a secret file.
+type Salts struct {
+	Hash    merkle.Hash
+	SaltHex string
+	Nonces  []*big.Int
+	RootHex string // the salted root they were made for, checked by Open
+}
+
+type saltsV1 struct {
+	Version int      `json:"version"`
+	Hash    string   `json:"hash,omitempty"` // empty is mimc
+	SaltHex string   `json:"salt_hex"`
+	Nonces  []string `json:"nonces"`
+	RootHex string   `json:"root_hex"`
+}
+
+// Salts splits the randomness off s, for keeping board and salt apart
+func (s *Secret) Salts() (*Salts, error) {
+	root, err := s.SaltedRoot()
+	if err != nil {
+		return nil, err
+	}
+	if !s.Blinded() {
+		return nil, fmt.Errorf("secret has no per-cell blinding, commit the board again")
+	}
+	return &Salts{
+		Hash:    s.Hash.Normalize(),
+		SaltHex: s.SaltHex,
+		Nonces:  s.Nonces,
+		RootHex: fmt.Sprintf("0x%x", root),
+	}, nil
+}
+
+// Open rebuilds the secret of board b, ErrRootMismatch if b isn't the
+// board these salts were committed with
+func (s *Salts) Open(b game.Board) (*Secret, error) {
+	if s.RootHex == "" {
+		// without it any board would open
+		return nil, errors.New("salt file has no root_hex to check the board against")
+	}
+	sec := &Secret{Board: b, SaltHex: s.SaltHex, Nonces: s.Nonces, Hash: s.Hash.Normalize()}
+	if _, err := sec.Salt(); err != nil {
+		return nil, err
+	}
+	if err := sec.rebuild(); err != nil {
+		return nil, err
+	}
+	root, err := sec.SaltedRoot()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(s.RootHex, fmt.Sprintf("0x%x", root)) {
+		return nil, ErrRootMismatch
+	}
+	return sec, nil
+}
+
+// LogValue keeps the salt and nonces out of logs, like Secret.LogValue
+func (s Salts) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("hash", string(s.Hash.Normalize())), slog.String("root", s.RootHex))
+}
+
+func (s Salts) MarshalJSON() ([]byte, error) {
+	nonces := make([]string, len(s.Nonces))
+	for i, n := range s.Nonces {
+		nonces[i] = fmt.Sprintf("0x%x", n)
+	}
+	return json.Marshal(saltsV1{
+		Version: SaltsVersion,
+		Hash:    string(s.Hash.Normalize()),
+		SaltHex: s.SaltHex,
+		Nonces:  nonces,
+		RootHex: s.RootHex,
+	})
+}
+
+func (s *Salts) UnmarshalJSON(data []byte) error {
+	var v saltsV1
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version != SaltsVersion {
+		return fmt.Errorf("unsupported salt file version %d", v.Version)
+	}
+	h, err := merkle.ParseHash(v.Hash)
+	if err != nil {
+		return err
+	}
+	if len(v.Nonces) != 100 {
+		return fmt.Errorf("salt file has %d nonces, want 100", len(v.Nonces))
+	}
+	*s = Salts{Hash: h, SaltHex: v.SaltHex, RootHex: v.RootHex, Nonces: make([]*big.Int, len(v.Nonces))}
+	for i, n := range v.Nonces {
+		x, ok := new(big.Int).SetString(strings.TrimPrefix(n, "0x"), 16)
+		if !ok {
+			return fmt.Errorf("bad nonce %d in salt file", i)
+		}
+		s.Nonces[i] = x
+	}
+	return nil
+}
+
+// SaveSalts writes s to path, encrypted when passphrase is non-empty.
+// The file is only readable by the owner either way.
+func SaveSalts(path string, s *Salts, passphrase []byte) error {
+	var v any = s
+	if len(passphrase) > 0 {
+		e, err := seal(kindSalts, s, passphrase)
+		if err != nil {
+			return err
+		}
+		v = e
+	}
+	return writePrivate(path, v)
+}
+
+// LoadSalts reads plain or encrypted salt files. Plain files ignore the passphrase.
+func LoadSalts(path string, passphrase []byte) (*Salts, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Salts
+	if !isEncrypted(data) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+	var e EncryptedSecret
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	if err := e.open(kindSalts, passphrase, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,110 @@
+package codec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"battleship-zk/internal/merkle"
+)
+
+// salts saved and loaded again, plain or encrypted, open the same commitment
+func TestSaltsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		hash merkle.Hash
+		pass string
+	}{
+		{"mimc", merkle.HashMiMC, ""},
+		{"poseidon2", merkle.HashPoseidon2, ""},
+		{"mimc encrypted", merkle.HashMiMC, "hunter2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sec := testSecret(t, tc.hash)
+			salts, err := sec.Salts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "salt.json")
+			if err := SaveSalts(path, salts, []byte(tc.pass)); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc := isEncrypted(data); enc != (tc.pass != "") || (enc && strings.Contains(string(data), sec.SaltHex)) {
+				t.Fatalf("salt file encrypted = %v with passphrase %q", enc, tc.pass)
+			}
+
+			got, err := LoadSalts(path, []byte(tc.pass))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := got.Open(sec.Board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := sec.SaltedRoot()
+			root, err := opened.SaltedRoot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root.Cmp(want) != 0 || opened.Hash != tc.hash || !opened.Blinded() {
+				t.Fatal("opened secret differs from the committed one")
+			}
+		})
+	}
+}
+
+func TestSaltsOpenWrongBoard(t *testing.T) {
+	sec := testSecret(t, merkle.HashMiMC)
+	salts, err := sec.Salts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := salts.Open(testBoard(t, 2)); !errors.Is(err, ErrRootMismatch) {
+		t.Fatalf("other board: err = %v, want %v", err, ErrRootMismatch)
+	}
+
+	// without the root any board would open
+	salts.RootHex = ""
+	if _, err := salts.Open(sec.Board); err == nil || !strings.Contains(err.Error(), "no root_hex") {
+		t.Fatalf("no root: err = %v, want no root_hex", err)
+	}
+}
+
+func TestLoadSaltsEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	sec := testSecret(t, merkle.HashMiMC)
+	salts, err := sec.Salts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltPath, secretPath := filepath.Join(dir, "salt.json"), filepath.Join(dir, "secret.json")
+	if err := SaveSalts(saltPath, salts, []byte("right")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSecret(secretPath, sec, []byte("right")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		load    func() error
+		wantErr error
+	}{
+		{"no passphrase", func() error { _, err := LoadSalts(saltPath, nil); return err }, ErrPassphraseRequired},
+		{"wrong passphrase", func() error { _, err := LoadSalts(saltPath, []byte("wrong")); return err }, ErrWrongPassphrase},
+		{"secret file as salts", func() error { _, err := LoadSalts(secretPath, []byte("right")); return err }, ErrWrongPassphrase},
+		{"salt file as secret", func() error { _, err := LoadSecret(saltPath, []byte("right")); return err }, ErrWrongPassphrase},
+	}
+	for _, tc := range tests {
+		if err := tc.load(); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package codec
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+)
+
+// SecretVersion is the current on-disk secret format. Version 0 (no version
+// field) stored the whole Merkle tree, version 2 only keeps board and salt and
+// rebuilds the tree when loading. There is no plain version 1: the encrypted
+// envelope (EncryptedSecret) is version 1, and plain files skip the number so
+// a version alone never names both formats.
+const SecretVersion = 2
+
+var ErrRootMismatch = errors.New("secret is corrupted: rebuilt root does not match stored root")
+
+// BuildBoardTree is the tree every commitment uses: 100 cells padded to 128 leaves.
+// nonces blind each cell leaf, nil gives the old unblinded tree.
+func BuildBoardTree(b game.Board, nonces []*big.Int, h merkle.Hash) (*merkle.Tree, error) {
+	return merkle.BuildTree(h.Normalize(), b.Flatten(), nonces, 128)
+}
+
+// NewBlindingNonces returns one random field element per board cell
+func NewBlindingNonces() ([]*big.Int, error) {
+	out := make([]*big.Int, 100)
+	for i := range out {
+		n, err := rand.Int(rand.Reader, fr.Modulus())
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// Blinded reports whether the secret has per-cell nonces. Secrets from
+// before blinding still load but cannot be proven with the current circuit.
+func (s *Secret) Blinded() bool { return len(s.Nonces) == 100 }
+
+func (s *Secret) Salt() (*big.Int, error) {
+	if s.SaltHex == "" || len(s.SaltHex) < 3 || s.SaltHex[:2] != "0x" {
+		return nil, fmt.Errorf("missing or invalid salt in secret")
+	}
+	salt, ok := new(big.Int).SetString(s.SaltHex[2:], 16)
+	if !ok {
+		return nil, fmt.Errorf("cannot parse salt hex")
+	}
+	return salt, nil
+}
+
+// SaltedRoot is the public commitment, H(salt, treeRoot)
+func (s *Secret) SaltedRoot() (*big.Int, error) {
+	if s.Tree == nil {
+		return nil, errors.New("secret has no merkle tree")
+	}
+	salt, err := s.Salt()
+	if err != nil {
+		return nil, err
+	}
+	return s.Hash.Normalize().Node(salt, s.Tree.Root()), nil
+}
+
+type secretV2 struct {
+	Version int        `json:"version"`
+	Board   game.Board `json:"board"`
+	Hash    string     `json:"hash,omitempty"` // empty is mimc
+	SaltHex string     `json:"salt_hex"`
+	Nonces  []string   `json:"nonces,omitempty"` // per-cell blinding, hex
+	RootHex string     `json:"root_hex"`         // checksum of the rebuilt tree, required
+}
+
+// old format, kept so existing secret.json files still load
+type secretV0 struct {
+	Board   game.Board   `json:"board"`
+	Tree    *merkle.Tree `json:"tree"`
+	SaltHex string       `json:"salt_hex"`
+}
+
+// LogValue keeps the board, salt, nonces and tree out of logs, slog
+// resolves it before the JSON handler would call MarshalJSON
+func (s Secret) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("hash", string(s.Hash.Normalize())),
+		slog.Bool("blinded", s.Blinded()),
+		slog.String("board", "redacted"),
+	)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	root, err := s.SaltedRoot()
+	if err != nil {
+		return nil, err
+	}
+	var nonces []string
+	for _, n := range s.Nonces {
+		nonces = append(nonces, fmt.Sprintf("0x%x", n))
+	}
+	return json.Marshal(secretV2{
+		Version: SecretVersion,
+		Board:   s.Board,
+		Hash:    string(s.Hash.Normalize()),
+		SaltHex: s.SaltHex,
+		Nonces:  nonces,
+		RootHex: fmt.Sprintf("0x%x", root),
+	})
+}
+
+func (s *Secret) UnmarshalJSON(data []byte) error {
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+
+	switch probe.Version {
+	case 0:
+		var old secretV0
+		if err := json.Unmarshal(data, &old); err != nil {
+			return err
+		}
+		*s = Secret{Board: old.Board, SaltHex: old.SaltHex, Hash: merkle.HashMiMC}
+		if err := s.rebuild(); err != nil {
+			return err
+		}
+		// the stored tree must agree with the board it claims to commit to
+		if old.Tree != nil && len(old.Tree.Levels) > 0 && old.Tree.Root().Cmp(s.Tree.Root()) != 0 {
+			return ErrRootMismatch
+		}
+		return nil
+	case SecretVersion:
+		var v secretV2
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		h, err := merkle.ParseHash(v.Hash)
+		if err != nil {
+			return err
+		}
+		*s = Secret{Board: v.Board, SaltHex: v.SaltHex, Hash: h}
+		if len(v.Nonces) > 0 {
+			if len(v.Nonces) != 100 {
+				return fmt.Errorf("secret has %d nonces, want 100", len(v.Nonces))
+			}
+			s.Nonces = make([]*big.Int, len(v.Nonces))
+			for i, h := range v.Nonces {
+				n, ok := new(big.Int).SetString(strings.TrimPrefix(h, "0x"), 16)
+				if !ok {
+					return fmt.Errorf("bad nonce %d in secret", i)
+				}
+				s.Nonces[i] = n
+			}
+		}
+		if err := s.rebuild(); err != nil {
+			return err
+		}
+		root, err := s.SaltedRoot()
+		if err != nil {
+			return err
+		}
+		if v.RootHex == "" {
+			return errors.New("secret has no root_hex checksum")
+		}
+		if !strings.EqualFold(v.RootHex, fmt.Sprintf("0x%x", root)) {
+			return ErrRootMismatch
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported secret version %d", probe.Version)
+}
+
+func (s *Secret) rebuild() error {
+	if err := s.Board.Validate(); err != nil {
+		return fmt.Errorf("secret board: %w", err)
+	}
+	t, err := BuildBoardTree(s.Board, s.Nonces, s.Hash)
+	if err != nil {
+		return err
+	}
+	s.Tree = t
+	return nil
+}
@@ -0,0 +1,135 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+)
+
+func testBoard(t *testing.T, seed uint64) game.Board {
+	t.Helper()
+	b, err := game.GenerateBoard(game.NewSeededRand(seed), game.StrategyUniform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func testSecret(t *testing.T, h merkle.Hash) *Secret {
+	t.Helper()
+	nonces, err := NewBlindingNonces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec := &Secret{Board: testBoard(t, 1), SaltHex: "0x1234abcd", Nonces: nonces, Hash: h}
+	if err := sec.rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	return sec
+}
+
+func TestSecretRoundTrip(t *testing.T) {
+	for _, h := range []merkle.Hash{merkle.HashMiMC, merkle.HashPoseidon2} {
+		t.Run(string(h), func(t *testing.T) {
+			sec := testSecret(t, h)
+			data, err := json.Marshal(sec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Secret
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			want, _ := sec.SaltedRoot()
+			root, err := got.SaltedRoot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root.Cmp(want) != 0 || got.Board != sec.Board || got.Hash != h || !got.Blinded() {
+				t.Fatalf("loaded secret differs from the saved one")
+			}
+		})
+	}
+}
+
+func TestSecretLegacyV0(t *testing.T) {
+	b := testBoard(t, 2)
+	tree, err := BuildBoardTree(b, nil, merkle.HashMiMC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := BuildBoardTree(testBoard(t, 3), nil, merkle.HashMiMC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tree    *merkle.Tree
+		wantErr error
+	}{
+		{"matching tree", tree, nil},
+		{"no tree", nil, nil},
+		{"tree of another board", other, ErrRootMismatch},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(secretV0{Board: b, Tree: tc.tree, SaltHex: "0x99"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sec Secret
+			err = json.Unmarshal(data, &sec)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if sec.Hash != merkle.HashMiMC || sec.Blinded() || sec.Tree.Root().Cmp(tree.Root()) != 0 {
+				t.Fatalf("v0 secret loaded as hash %q, blinded %v", sec.Hash, sec.Blinded())
+			}
+		})
+	}
+}
+
+func TestSecretTampered(t *testing.T) {
+	data, err := json.Marshal(testSecret(t, merkle.HashMiMC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(m map[string]any)
+		wantErr string
+	}{
+		{"salt", func(m map[string]any) { m["salt_hex"] = "0x1234abce" }, ErrRootMismatch.Error()},
+		{"nonce", func(m map[string]any) { m["nonces"].([]any)[5] = "0x7" }, ErrRootMismatch.Error()},
+		{"root", func(m map[string]any) { m["root_hex"] = "0x1" }, ErrRootMismatch.Error()},
+		{"hash", func(m map[string]any) { m["hash"] = "poseidon2" }, ErrRootMismatch.Error()},
+		{"missing root", func(m map[string]any) { delete(m, "root_hex") }, "no root_hex"},
+		{"empty root", func(m map[string]any) { m["root_hex"] = "" }, "no root_hex"},
+		{"version 1", func(m map[string]any) { m["version"] = 1 }, "unsupported secret version 1"},
+		{"short nonces", func(m map[string]any) { m["nonces"] = m["nonces"].([]any)[:99] }, "99 nonces"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			tc.edit(m)
+			edited, _ := json.Marshal(m)
+			var sec Secret
+			err := json.Unmarshal(edited, &sec)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	ErrPassphraseRequired = errors.New("file is encrypted: passphrase required")
+	ErrWrongPassphrase    = errors.New("cannot decrypt file: wrong passphrase or corrupted file")
+)
+
+const encryptedSecretVersion = 1
+
+// argon2id parameters, stored in the file so we can raise them later
+type kdfParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory_kib"`
+	Threads uint8  `json:"threads"`
+}
+
+var defaultKDF = kdfParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// EncryptedSecret is what goes on disk instead of the plain Secret json,
+// and instead of the plain Salts json of an encrypted salt file
+type EncryptedSecret struct {
+	Version    int       `json:"version"`
+	KDF        string    `json:"kdf"`
+	KDFParams  kdfParams `json:"kdf_params"`
+	Cipher     string    `json:"cipher"`
+	Salt       []byte    `json:"salt"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+func deriveKey(passphrase []byte, salt []byte, p kdfParams) []byte {
+	return argon2.IDKey(passphrase, salt, p.Time, p.Memory, p.Threads, 32)
+}
+
+// kinds of encrypted file, part of the header
+const (
+	kindSecret = "secret"
+	kindSalts  = "salts"
+)
+
+// header fields and the kind are authenticated so they can't be swapped
+// around, nor a salt file passed off as a secret file
+func (e *EncryptedSecret) aad(kind string) []byte {
+	return fmt.Appendf(nil, "battleship-zk %s v%d %s %d/%d/%d %s",
+		kind, e.Version, e.KDF, e.KDFParams.Time, e.KDFParams.Memory, e.KDFParams.Threads, e.Cipher)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func EncryptSecret(sec *Secret, passphrase []byte) (*EncryptedSecret, error) {
+	return seal(kindSecret, sec, passphrase)
+}
+
+func DecryptSecret(e *EncryptedSecret, passphrase []byte) (*Secret, error) {
+	var sec Secret
+	if err := e.open(kindSecret, passphrase, &sec); err != nil {
+		return nil, err
+	}
+	return &sec, nil
+}
+
+// seal encrypts the json of v with a key derived from passphrase
+func seal(kind string, v any, passphrase []byte) (*EncryptedSecret, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	e := &EncryptedSecret{
+		Version:   encryptedSecretVersion,
+		KDF:       "argon2id",
+		KDFParams: defaultKDF,
+		Cipher:    "aes-256-gcm",
+		Salt:      make([]byte, 16),
+	}
+	if _, err := rand.Read(e.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(deriveKey(passphrase, e.Salt, e.KDFParams))
+	if err != nil {
+		return nil, err
+	}
+	e.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, err
+	}
+	e.Ciphertext = aead.Seal(nil, e.Nonce, plain, e.aad(kind))
+	return e, nil
+}
+
+// open decrypts e into v, the reverse of seal
+func (e *EncryptedSecret) open(kind string, passphrase []byte, v any) error {
+	if e.Version != encryptedSecretVersion {
+		return fmt.Errorf("unsupported encrypted %s version %d", kind, e.Version)
+	}
+	if e.KDF != "argon2id" || e.Cipher != "aes-256-gcm" {
+		return fmt.Errorf("unsupported encryption %s/%s", e.KDF, e.Cipher)
+	}
+	if len(passphrase) == 0 {
+		return ErrPassphraseRequired
+	}
+	aead, err := newGCM(deriveKey(passphrase, e.Salt, e.KDFParams))
+	if err != nil {
+		return err
+	}
+	if len(e.Nonce) != aead.NonceSize() {
+		return fmt.Errorf("encrypted %s has bad nonce size", kind)
+	}
+	plain, err := aead.Open(nil, e.Nonce, e.Ciphertext, e.aad(kind))
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// IsEncryptedSecret reports whether the file at path holds an encrypted
+// secret or salt file
+func IsEncryptedSecret(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	return isEncrypted(data), nil
+}
+
+func isEncrypted(data []byte) bool {
+	var probe struct {
+		KDF        string `json:"kdf"`
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return probe.KDF != "" && len(probe.Ciphertext) > 0
+}
+
+// SaveSecret writes sec to path, encrypted when passphrase is non-empty.
+// The file is only readable by the owner either way.
+func SaveSecret(path string, sec *Secret, passphrase []byte) error {
+	var v any = sec
+	if len(passphrase) > 0 {
+		e, err := EncryptSecret(sec, passphrase)
+		if err != nil {
+			return err
+		}
+		v = e
+	}
+	return writePrivate(path, v)
+}
+
+// writePrivate writes the json of v to path, only readable by the owner
+func writePrivate(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0o600)
+}
+
+// LoadSecret reads plain or encrypted secret files. Plain files ignore the passphrase.
+func LoadSecret(path string, passphrase []byte) (*Secret, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isEncrypted(data) {
+		var sec Secret
+		if err := json.Unmarshal(data, &sec); err != nil {
+			return nil, err
+		}
+		return &sec, nil
+	}
+	var e EncryptedSecret
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return DecryptSecret(&e, passphrase)
+}
@@ -1,19 +1,27 @@
 package codec
 
 import (
+	"math/big"
+
 	"battleship-zk/internal/game"
 	"battleship-zk/internal/merkle"
 	"battleship-zk/internal/zk"
 )
 
+// Secret is the defender state. Tree is derived from Board and is not
+// serialized, see secret.go for the on-disk format.
 type Secret struct {
-	Board game.Board   `json:"board"`
-	Tree  *merkle.Tree `json:"tree"`
-	SaltHex string       `json:"salt_hex"`
-
+	Board   game.Board
+	Tree    *merkle.Tree
+	SaltHex string
+	Nonces  []*big.Int // per-cell leaf blinding, row-major like Board.Flatten
+	Hash    merkle.Hash
 }
 
+// ShotProofPayload is the proof and its public inputs, see wire.go for the JSON format
 type ShotProofPayload struct {
-	Proof  []byte        `json:"proof"`
-	Public zk.ShotPublic `json:"public"` // contains root and the hit and the row and col
+	Proof  []byte        // gnark compressed proof bytes
+	Public zk.ShotPublic // contains root and the hit and the row and col
+	// how MarshalJSON writes Proof, empty is EncodingPoints
+	Encoding ProofEncoding
 }
\ No newline at end of file
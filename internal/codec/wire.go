@@ -0,0 +1,224 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/zk"
+)
+
+// WireVersion is the proof payload format written by MarshalJSON. Field
+// elements are 0x-hex strings so JSON clients never round them through a float.
+const WireVersion = 1
+
+type ProofEncoding string
+
+const (
+	EncodingPoints     ProofEncoding = "points"     // proof as named affine points a, b, c
+	EncodingCompressed ProofEncoding = "compressed" // gnark compressed bytes, 0x-hex
+)
+
+func ParseProofEncoding(name string) (ProofEncoding, error) {
+	switch ProofEncoding(name) {
+	case "", EncodingPoints:
+		return EncodingPoints, nil
+	case EncodingCompressed:
+		return EncodingCompressed, nil
+	}
+	return "", fmt.Errorf("unknown proof encoding %q (want points or compressed)", name)
+}
+
+type wirePayload struct {
+	Version         int        `json:"version"`
+	Curve           string     `json:"curve"`
+	Scheme          string     `json:"scheme"`
+	Hash            string     `json:"hash"`
+	Public          wirePublic `json:"public"`
+	Proof           *wireProof `json:"proof,omitempty"`
+	ProofCompressed string     `json:"proof_compressed,omitempty"`
+}
+
+type wirePublic struct {
+	Root string `json:"root"`
+	Hit  *uint8 `json:"hit"`
+	Row  *uint8 `json:"row"`
+	Col  *uint8 `json:"col"`
+}
+
+type wireProof struct {
+	A [2]string    `json:"a"`
+	B [2][2]string `json:"b"`
+	C [2]string    `json:"c"`
+}
+
+// the format before WireVersion: base64 proof and a bare JSON number root
+type legacyPayload struct {
+	Proof  []byte        `json:"proof"`
+	Public zk.ShotPublic `json:"public"`
+}
+
+func hexFE(x *big.Int) string { return fmt.Sprintf("0x%064x", x) }
+
+// ParseFieldHex parses a 0x-prefixed hex scalar field element, rejecting
+// anything that is not already reduced
+func ParseFieldHex(s string) (*big.Int, error) {
+	return parseHexBelow(s, fr.Modulus())
+}
+
+func parseHexBelow(s string, modulus *big.Int) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 3 || (s[:2] != "0x" && s[:2] != "0X") {
+		return nil, fmt.Errorf("field element %q must be 0x-prefixed hex", s)
+	}
+	n, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok {
+		return nil, fmt.Errorf("field element %q is not valid hex", s)
+	}
+	if n.Cmp(modulus) >= 0 {
+		return nil, fmt.Errorf("field element %q is not reduced", s)
+	}
+	return n, nil
+}
+
+func (p ShotProofPayload) MarshalJSON() ([]byte, error) {
+	if p.Public.Root == nil {
+		return nil, errors.New("proof payload missing public root")
+	}
+	hit, row, col := p.Public.Hit, p.Public.Row, p.Public.Col
+	w := wirePayload{
+		Version: WireVersion,
+		Curve:   "bn254",
+		Scheme:  "groth16",
+		Hash:    string(merkle.Hash(p.Public.Hash).Normalize()),
+		Public:  wirePublic{Root: hexFE(p.Public.Root), Hit: &hit, Row: &row, Col: &col},
+	}
+	enc, err := ParseProofEncoding(string(p.Encoding))
+	if err != nil {
+		return nil, err
+	}
+	if enc == EncodingCompressed {
+		w.ProofCompressed = "0x" + hex.EncodeToString(p.Proof)
+	} else {
+		pts, err := zk.PointsFromProof(p.Proof)
+		if err != nil {
+			return nil, err
+		}
+		w.Proof = &wireProof{
+			A: [2]string{hexFE(pts.A[0]), hexFE(pts.A[1])},
+			B: [2][2]string{
+				{hexFE(pts.B[0][0]), hexFE(pts.B[0][1])},
+				{hexFE(pts.B[1][0]), hexFE(pts.B[1][1])},
+			},
+			C: [2]string{hexFE(pts.C[0]), hexFE(pts.C[1])},
+		}
+	}
+	return json.Marshal(w)
+}
+
+// UnmarshalJSON is strict for versioned payloads: unknown fields, missing
+// public values, unreduced field elements and invalid curve points are errors.
+// Payloads without a version are read in the legacy format.
+func (p *ShotProofPayload) UnmarshalJSON(data []byte) error {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+	if _, ok := probe["version"]; !ok {
+		var old legacyPayload
+		if err := json.Unmarshal(data, &old); err != nil {
+			return err
+		}
+		if err := zk.CheckProofBytes(old.Proof); err != nil {
+			return err
+		}
+		*p = ShotProofPayload{Proof: old.Proof, Public: old.Public, Encoding: EncodingCompressed}
+		return nil
+	}
+
+	var w wirePayload
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&w); err != nil {
+		return fmt.Errorf("proof payload: %w", err)
+	}
+	if w.Version != WireVersion {
+		return fmt.Errorf("unsupported proof payload version %d", w.Version)
+	}
+	if w.Curve != "bn254" || w.Scheme != "groth16" {
+		return fmt.Errorf("unsupported proof %s/%s, want bn254/groth16", w.Curve, w.Scheme)
+	}
+	h, err := merkle.ParseHash(w.Hash)
+	if err != nil {
+		return err
+	}
+	if w.Public.Hit == nil || w.Public.Row == nil || w.Public.Col == nil {
+		return errors.New("proof payload: public hit, row and col are required")
+	}
+	if *w.Public.Hit > 1 || *w.Public.Row > 9 || *w.Public.Col > 9 {
+		return errors.New("proof payload: public hit/row/col out of range")
+	}
+	root, err := ParseFieldHex(w.Public.Root)
+	if err != nil {
+		return fmt.Errorf("proof payload root: %w", err)
+	}
+
+	out := ShotProofPayload{
+		Public: zk.ShotPublic{Root: root, Hit: *w.Public.Hit, Row: *w.Public.Row, Col: *w.Public.Col, Hash: string(h)},
+	}
+	switch {
+	case w.Proof != nil && w.ProofCompressed != "":
+		return errors.New("proof payload has both proof and proof_compressed")
+	case w.Proof != nil:
+		var pts zk.ProofPoints
+		coords := []struct {
+			dst **big.Int
+			s   string
+		}{
+			{&pts.A[0], w.Proof.A[0]}, {&pts.A[1], w.Proof.A[1]},
+			{&pts.B[0][0], w.Proof.B[0][0]}, {&pts.B[0][1], w.Proof.B[0][1]},
+			{&pts.B[1][0], w.Proof.B[1][0]}, {&pts.B[1][1], w.Proof.B[1][1]},
+			{&pts.C[0], w.Proof.C[0]}, {&pts.C[1], w.Proof.C[1]},
+		}
+		for _, c := range coords {
+			if *c.dst, err = parseHexBelow(c.s, fp.Modulus()); err != nil {
+				return fmt.Errorf("proof point: %w", err)
+			}
+		}
+		if out.Proof, err = zk.ProofFromPoints(&pts); err != nil {
+			return err
+		}
+		out.Encoding = EncodingPoints
+	case w.ProofCompressed != "":
+		raw, err := hex.DecodeString(strings.TrimPrefix(w.ProofCompressed, "0x"))
+		if err != nil || !strings.HasPrefix(w.ProofCompressed, "0x") {
+			return errors.New("proof_compressed must be 0x-prefixed hex")
+		}
+		if err := zk.CheckProofBytes(raw); err != nil {
+			return err
+		}
+		out.Proof = raw
+		out.Encoding = EncodingCompressed
+	default:
+		return errors.New("proof payload has no proof")
+	}
+	*p = out
+	return nil
+}
+
+// DecodeShotProof decodes a payload the same way for CLI files and HTTP bodies
+func DecodeShotProof(data []byte) (*ShotProofPayload, error) {
+	var p ShotProofPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,199 @@
+package codec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/zk"
+)
+
+// testPayload has valid curve points built from the generators, it decodes
+// like a real proof but does not verify
+func testPayload(t *testing.T) ShotProofPayload {
+	t.Helper()
+	_, _, g1, g2 := bn254.Generators()
+	var c bn254.G1Affine
+	c.Double(&g1)
+	bi := func(e fp.Element) *big.Int { return e.BigInt(new(big.Int)) }
+	proof, err := zk.ProofFromPoints(&zk.ProofPoints{
+		A: [2]*big.Int{bi(g1.X), bi(g1.Y)},
+		B: [2][2]*big.Int{{bi(g2.X.A0), bi(g2.X.A1)}, {bi(g2.Y.A0), bi(g2.Y.A1)}},
+		C: [2]*big.Int{bi(c.X), bi(c.Y)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ShotProofPayload{
+		Proof:  proof,
+		Public: zk.ShotPublic{Root: big.NewInt(12345), Hit: 1, Row: 3, Col: 7, Hash: string(merkle.HashPoseidon2)},
+	}
+}
+
+func TestShotProofRoundTrip(t *testing.T) {
+	p := testPayload(t)
+	tests := []struct {
+		name string
+		enc  ProofEncoding
+		key  string // proof field written
+	}{
+		{"default", "", "proof"},
+		{"points", EncodingPoints, "proof"},
+		{"compressed", EncodingCompressed, "proof_compressed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			in := p
+			in.Encoding = tc.enc
+			data, err := json.Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := m[tc.key]; !ok || m["version"] != float64(WireVersion) {
+				t.Fatalf("payload %s has no %s or version", data, tc.key)
+			}
+
+			got, err := DecodeShotProof(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := p
+			want.Encoding = tc.enc
+			if want.Encoding == "" {
+				want.Encoding = EncodingPoints
+			}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("decoded %+v, want %+v", *got, want)
+			}
+		})
+	}
+
+	// the zero hash is written as mimc
+	in := p
+	in.Public.Hash = ""
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeShotProof(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Public.Hash != string(merkle.HashMiMC) {
+		t.Errorf("hash %q, want %q", got.Public.Hash, merkle.HashMiMC)
+	}
+}
+
+// payloads from before WireVersion: base64 proof and a numeric root
+func TestShotProofLegacy(t *testing.T) {
+	p := testPayload(t)
+	data, err := json.Marshal(legacyPayload{Proof: p.Proof, Public: p.Public})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeShotProof(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Encoding != EncodingCompressed || !reflect.DeepEqual(got.Proof, p.Proof) || got.Public.Root.Cmp(p.Public.Root) != 0 || got.Public.Row != 3 {
+		t.Errorf("legacy payload decoded as %+v", got)
+	}
+
+	bad := append([]byte(nil), p.Proof...)
+	bad[0] ^= 0xff
+	data, _ = json.Marshal(legacyPayload{Proof: bad, Public: p.Public})
+	if _, err := DecodeShotProof(data); err == nil {
+		t.Error("legacy payload with a broken proof decoded")
+	}
+}
+
+func TestShotProofRejects(t *testing.T) {
+	p := testPayload(t)
+	points, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Encoding = EncodingCompressed
+	compressed, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cm map[string]any
+	if err := json.Unmarshal(compressed, &cm); err != nil {
+		t.Fatal(err)
+	}
+
+	r, q := fr.Modulus(), fp.Modulus()
+	proof := func(m map[string]any) map[string]any { return m["proof"].(map[string]any) }
+	public := func(m map[string]any) map[string]any { return m["public"].(map[string]any) }
+	tests := []struct {
+		name    string
+		edit    func(m map[string]any)
+		wantErr string
+	}{
+		{"unknown field", func(m map[string]any) { m["extra"] = 1 }, "unknown field"},
+		{"unknown public field", func(m map[string]any) { public(m)["cell"] = 1 }, "unknown field"},
+		{"missing hit", func(m map[string]any) { delete(public(m), "hit") }, "required"},
+		{"missing row", func(m map[string]any) { delete(public(m), "row") }, "required"},
+		{"missing col", func(m map[string]any) { delete(public(m), "col") }, "required"},
+		{"hit out of range", func(m map[string]any) { public(m)["hit"] = 2 }, "out of range"},
+		{"col out of range", func(m map[string]any) { public(m)["col"] = 10 }, "out of range"},
+		{"root not reduced", func(m map[string]any) { public(m)["root"] = hexFE(r) }, "not reduced"},
+		{"root not hex", func(m map[string]any) { public(m)["root"] = "12345" }, "0x-prefixed"},
+		{"point not reduced", func(m map[string]any) { proof(m)["a"].([]any)[0] = hexFE(q) }, "not reduced"},
+		{"a off the curve", func(m map[string]any) {
+			a := proof(m)["a"].([]any)
+			y, _ := new(big.Int).SetString(a[1].(string)[2:], 16)
+			a[1] = hexFE(y.Add(y, big.NewInt(1)))
+		}, "not a valid G1 point"},
+		{"b off the curve", func(m map[string]any) { proof(m)["b"].([]any)[0].([]any)[0] = hexFE(big.NewInt(1)) }, "not a valid G2 point"},
+		{"both proofs", func(m map[string]any) { m["proof_compressed"] = cm["proof_compressed"] }, "both proof and proof_compressed"},
+		{"no proof", func(m map[string]any) { delete(m, "proof") }, "no proof"},
+		{"compressed not hex", func(m map[string]any) {
+			delete(m, "proof")
+			m["proof_compressed"] = strings.TrimPrefix(cm["proof_compressed"].(string), "0x")
+		}, "0x-prefixed hex"},
+		{"version 2", func(m map[string]any) { m["version"] = 2 }, "version 2"},
+		{"curve", func(m map[string]any) { m["curve"] = "bls12-381" }, "bls12-381/groth16"},
+		{"scheme", func(m map[string]any) { m["scheme"] = "plonk" }, "bn254/plonk"},
+		{"hash", func(m map[string]any) { m["hash"] = "sha256" }, "sha256"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var m map[string]any
+			if err := json.Unmarshal(points, &m); err != nil {
+				t.Fatal(err)
+			}
+			tc.edit(m)
+			data, _ := json.Marshal(m)
+			_, err := DecodeShotProof(data)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	// a compressed proof whose b is no longer in the G2 subgroup
+	raw, err := hex.DecodeString(strings.TrimPrefix(cm["proof_compressed"].(string), "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[40] ^= 1
+	cm["proof_compressed"] = "0x" + hex.EncodeToString(raw)
+	data, _ := json.Marshal(cm)
+	if _, err := DecodeShotProof(data); err == nil {
+		t.Errorf("tampered proof_compressed decoded: %s", data)
+	}
+}
@@ -0,0 +1,273 @@
+// Package config is the battleship.toml file shared by the CLI commands.
+// Values are layered: built-in defaults, then the file, then BATTLESHIP_*
+// environment variables, then command line flags (applied by cmd).
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/limits"
+	"battleship-zk/internal/logging"
+	"battleship-zk/internal/merkle"
+)
+
+// EnvPrefix starts every override variable. The name of a key is its path
+// in the file, upper case and joined with _, e.g. serve.tls.cert is
+// BATTLESHIP_SERVE_TLS_CERT.
+const EnvPrefix = "BATTLESHIP_"
+
+// EnvFile names the config file when --config isn't given
+const EnvFile = EnvPrefix + "CONFIG"
+
+type Config struct {
+	Keys    string `toml:"keys"`    // keys directory
+	Secret  string `toml:"secret"`  // defender secret file
+	Encrypt bool   `toml:"encrypt"` // keep the secret encrypted on disk
+	VK      string `toml:"vk"`      // verifying key, default the one in keys for game.hash
+
+	Game  Game  `toml:"game"`
+	Log   Log   `toml:"log"`
+	Serve Serve `toml:"serve"`
+}
+
+// Game is the ruleset both players have to agree on
+type Game struct {
+	Hash     string `toml:"hash"`     // merkle hash of the commitment: mimc or poseidon2
+	Strategy string `toml:"strategy"` // ship placement of init
+}
+
+type Log struct {
+	Level  string `toml:"level"`  // debug, info, warn or error
+	Format string `toml:"format"` // text or json
+}
+
+type Serve struct {
+	Addr      string `toml:"addr"`
+	GRPCAddr  string `toml:"grpc_addr"`  // empty is no gRPC
+	State     string `toml:"state"`      // default next to secret
+	PublicURL string `toml:"public_url"` // our base url as the peer reaches it
+	Peer      string `toml:"peer"`       // the opponent's server, registered at start
+
+	PersistPlaintext bool `toml:"persist_plaintext"` // save API commits without encrypt
+
+	Timeouts Timeouts `toml:"timeouts"`
+	TLS      TLS      `toml:"tls"`
+	CORS     CORS     `toml:"cors"`
+	Limits   Limits   `toml:"limits"`
+}
+
+type Timeouts struct {
+	Read     time.Duration `toml:"read"`
+	Write    time.Duration `toml:"write"` // shoot includes proving
+	Idle     time.Duration `toml:"idle"`
+	Shutdown time.Duration `toml:"shutdown"`
+}
+
+type TLS struct {
+	Cert       string   `toml:"cert"`
+	Key        string   `toml:"key"`
+	SelfSigned bool     `toml:"self_signed"` // generate cert and key if missing
+	Name       string   `toml:"name"`        // player name of a generated cert
+	Hosts      []string `toml:"hosts"`       // dns names and ips of a generated cert
+	PeerCA     string   `toml:"peer_ca"`     // turns on mTLS between the servers
+}
+
+type CORS struct {
+	Origins []string            `toml:"origins"`
+	Peer    bool                `toml:"peer"`    // the peer's origin, read-only routes only
+	Hosts   []string            `toml:"hosts"`   // names our own UI is served under
+	Methods map[string][]string `toml:"methods"` // route to methods, file only
+}
+
+type Limits struct {
+	MaxBody      int64   `toml:"max_body"`
+	Rate         float64 `toml:"rate"`
+	Burst        int     `toml:"burst"`
+	ProveWorkers int     `toml:"prove_workers"`
+	ProveQueue   int     `toml:"prove_queue"`
+}
+
+// Default is the configuration without a file or environment
+func Default() Config {
+	hostname, _ := os.Hostname()
+	l := limits.Default
+	return Config{
+		Keys:   "./keys",
+		Secret: "secret.json",
+		Game:   Game{Hash: string(merkle.HashMiMC), Strategy: string(game.StrategyUniform)},
+		Log:    Log{Level: "info", Format: "text"},
+		Serve: Serve{
+			Addr: ":8080",
+			Timeouts: Timeouts{
+				Read:     15 * time.Second,
+				Write:    2 * time.Minute,
+				Idle:     60 * time.Second,
+				Shutdown: 30 * time.Second,
+			},
+			TLS: TLS{
+				Name:  hostname,
+				Hosts: []string{"localhost", "127.0.0.1", "::1", hostname},
+			},
+			CORS: CORS{Peer: true},
+			Limits: Limits{
+				MaxBody:      l.MaxBodyBytes,
+				Rate:         l.Rate,
+				Burst:        l.Burst,
+				ProveWorkers: l.ProveWorkers,
+				ProveQueue:   l.ProveQueue,
+			},
+		},
+	}
+}
+
+// Load is Default with the file at path (if not empty) and the environment
+// applied on top
+func Load(path string) (Config, error) {
+	c := Default()
+	if path != "" {
+		md, err := toml.DecodeFile(path, &c)
+		if err != nil {
+			return c, fmt.Errorf("config %s: %w", path, err)
+		}
+		if und := md.Undecoded(); len(und) > 0 {
+			return c, fmt.Errorf("config %s: unknown key %s", path, und[0])
+		}
+	}
+	if err := c.applyEnv(os.LookupEnv); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// Validate checks the values a typo would otherwise only break later
+func (c Config) Validate() error {
+	if _, err := merkle.ParseHash(c.Game.Hash); err != nil {
+		return fmt.Errorf("game.hash: %w", err)
+	}
+	if _, err := game.ParseStrategy(c.Game.Strategy); err != nil {
+		return fmt.Errorf("game.strategy: %w", err)
+	}
+	if _, err := logging.New(io.Discard, c.Log.Level, c.Log.Format); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+	return nil
+}
+
+// Hash is Game.Hash parsed, call it after Validate
+func (c Config) Hash() merkle.Hash {
+	h, _ := merkle.ParseHash(c.Game.Hash)
+	return h
+}
+
+// TOML is c as a config file
+func (c Config) TOML() ([]byte, error) {
+	var b strings.Builder
+	enc := toml.NewEncoder(&b)
+	enc.Indent = ""
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// Env lists the override variables of c, in file order
+func (c Config) Env() []string {
+	var names []string
+	_ = walkEnv(reflect.ValueOf(&c).Elem(), EnvPrefix, func(name string, _ reflect.Value) error {
+		names = append(names, name)
+		return nil
+	})
+	return names
+}
+
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	return walkEnv(reflect.ValueOf(c).Elem(), EnvPrefix, func(name string, v reflect.Value) error {
+		s, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		if err := setValue(v, strings.TrimSpace(s)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// walkEnv calls f with the variable name of every settable field under v,
+// maps are left to the file
+func walkEnv(v reflect.Value, prefix string, f func(string, reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		name := prefix + strings.ToUpper(key)
+		fv := v.Field(i)
+		switch fv.Kind() {
+		case reflect.Struct:
+			if err := walkEnv(fv, name+"_", f); err != nil {
+				return err
+			}
+		case reflect.Map:
+		default:
+			if err := f(name, fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		// comma separated
+		var items []string
+		for _, it := range strings.Split(s, ",") {
+			if it = strings.TrimSpace(it); it != "" {
+				items = append(items, it)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"battleship-zk/internal/limits"
+)
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "battleship.toml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// a key set in a later layer wins, the others keep the earlier value
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+keys = "./keys-file"
+[serve]
+addr = ":1"
+[serve.limits]
+rate = 1
+burst = 2
+[serve.timeouts]
+read = "3s"
+`)
+	t.Setenv(EnvPrefix+"SERVE_LIMITS_RATE", "7.5")
+	t.Setenv(EnvPrefix+"SERVE_TIMEOUTS_READ", " 4s ")
+	t.Setenv(EnvPrefix+"SERVE_TLS_HOSTS", "a.example, ,b.example")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Default()
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"default secret", c.Secret, d.Secret},
+		{"default hash", c.Game.Hash, d.Game.Hash},
+		{"default prove queue", c.Serve.Limits.ProveQueue, limits.Default.ProveQueue},
+		{"default max body", c.Serve.Limits.MaxBody, limits.Default.MaxBodyBytes},
+		{"file keys", c.Keys, "./keys-file"},
+		{"file addr", c.Serve.Addr, ":1"},
+		{"file burst", c.Serve.Limits.Burst, 2},
+		{"env over file rate", c.Serve.Limits.Rate, 7.5},
+		{"env over file read timeout", c.Serve.Timeouts.Read, 4 * time.Second},
+		{"env list", c.Serve.TLS.Hosts, []string{"a.example", "b.example"}},
+	}
+	for _, tc := range tests {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{"unknown key", "[serve]\nadr = \":1\"\n", nil, "unknown key serve.adr"},
+		{"bad hash", "[game]\nhash = \"sha1\"\n", nil, "game.hash"},
+		{"bad env value", "", map[string]string{EnvPrefix + "SERVE_LIMITS_BURST": "many"}, EnvPrefix + "SERVE_LIMITS_BURST"},
+		{"bad env level", "", map[string]string{EnvPrefix + "LOG_LEVEL": "loud"}, "log"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(writeFile(t, tc.file))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load: %v, want an error with %q", err, tc.want)
+			}
+		})
+	}
+}
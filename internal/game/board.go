@@ -2,7 +2,6 @@ package game
 
 import (
 	"errors"
-	"math/rand"
 )
 
 type Board struct { Cells [10][10]uint8 }
@@ -30,26 +29,7 @@ func (b *Board) Flatten() []uint8 {
 	return out
 }
 
-// this has no overlap
+// this has no overlap, uses crypto/rand and the uniform strategy
 func GenerateRandomBoard() (Board, error) {
-	var b Board
-	tries := 0
-	for _, L := range shipSizes {
-	retry:
-		if tries > 10000 { return Board{}, errors.New("failed to place ships") }
-		tries++
-		vert := rand.Intn(2) == 0
-		r := rand.Intn(10)
-		c := rand.Intn(10)
-		if vert {
-			if r+L > 10 { goto retry }
-			for i:=0; i<L; i++ { if b.Cells[r+i][c] == 1 { goto retry } }
-			for i:=0; i<L; i++ { b.Cells[r+i][c] = 1 }
-		} else {
-			if c+L > 10 { goto retry }
-			for i:=0; i<L; i++ { if b.Cells[r][c+i] == 1 { goto retry } }
-			for i:=0; i<L; i++ { b.Cells[r][c+i] = 1 }
-		}
-	}
-	return b, nil
-}
\ No newline at end of file
+	return GenerateBoard(NewCryptoRand(), StrategyUniform)
+}
@@ -0,0 +1,218 @@
+package game
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// Strategy picks how ships are laid out on the board. Every strategy
+// produces a valid board, they only differ in which placements are preferred.
+type Strategy string
+
+const (
+	StrategyUniform   Strategy = "uniform"    // every legal placement equally likely
+	StrategyEdgeAvoid Strategy = "edge-avoid" // prefer ships away from the border
+	StrategySpread    Strategy = "spread"     // prefer ships that don't touch each other
+	StrategyAntiHunt  Strategy = "anti-hunt"  // prefer cells a density-based hunter checks last
+)
+
+var strategies = []Strategy{StrategyUniform, StrategyEdgeAvoid, StrategySpread, StrategyAntiHunt}
+
+func Strategies() []Strategy {
+	out := make([]Strategy, len(strategies))
+	copy(out, strategies)
+	return out
+}
+
+// empty name gives the default (uniform)
+func ParseStrategy(name string) (Strategy, error) {
+	if name == "" {
+		return StrategyUniform, nil
+	}
+	for _, s := range strategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown placement strategy %q", name)
+}
+
+// NewSeededRand is deterministic, same seed gives the same boards
+func NewSeededRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// NewCryptoRand reads from crypto/rand, use this for real games
+func NewCryptoRand() *rand.Rand {
+	return rand.New(cryptoSource{})
+}
+
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+type placement struct {
+	r, c, size int
+	vert       bool
+}
+
+func (p placement) cells() [][2]int {
+	out := make([][2]int, p.size)
+	for i := 0; i < p.size; i++ {
+		if p.vert {
+			out[i] = [2]int{p.r + i, p.c}
+		} else {
+			out[i] = [2]int{p.r, p.c + i}
+		}
+	}
+	return out
+}
+
+func (p placement) fits(b *Board) bool {
+	for _, rc := range p.cells() {
+		if rc[0] > 9 || rc[1] > 9 || b.Cells[rc[0]][rc[1]] == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// weight of a placement given the ships already on the board, must be > 0
+type weightFunc func(b *Board, p placement) int
+
+func (s Strategy) weight() (weightFunc, error) {
+	switch s {
+	case StrategyUniform, "":
+		return func(*Board, placement) int { return 1 }, nil
+	case StrategyEdgeAvoid:
+		return edgeAvoidWeight, nil
+	case StrategySpread:
+		return spreadWeight, nil
+	case StrategyAntiHunt:
+		return antiHuntWeight, nil
+	}
+	return nil, fmt.Errorf("unknown placement strategy %q", s)
+}
+
+func edgeAvoidWeight(_ *Board, p placement) int {
+	w := 1
+	for _, rc := range p.cells() {
+		if rc[0] > 0 && rc[0] < 9 && rc[1] > 0 && rc[1] < 9 {
+			w += 2
+		}
+	}
+	return w
+}
+
+func spreadWeight(b *Board, p placement) int {
+	for _, rc := range p.cells() {
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				r, c := rc[0]+dr, rc[1]+dc
+				if r >= 0 && r < 10 && c >= 0 && c < 10 && b.Cells[r][c] == 1 {
+					return 1
+				}
+			}
+		}
+	}
+	return 8
+}
+
+// how many ways the fleet can cover each cell on an empty board,
+// this is the heatmap a probability-density hunter starts from
+var huntDensity = func() (d [10][10]int) {
+	var empty Board
+	for _, L := range shipSizes {
+		for _, p := range candidates(&empty, L) {
+			for _, rc := range p.cells() {
+				d[rc[0]][rc[1]]++
+			}
+		}
+	}
+	return d
+}()
+
+var huntDensityMax = func() int {
+	m := 0
+	for r := 0; r < 10; r++ {
+		for c := 0; c < 10; c++ {
+			if huntDensity[r][c] > m {
+				m = huntDensity[r][c]
+			}
+		}
+	}
+	return m
+}()
+
+func antiHuntWeight(_ *Board, p placement) int {
+	sum := 0
+	for _, rc := range p.cells() {
+		sum += huntDensity[rc[0]][rc[1]]
+	}
+	// cold cells get more weight, +1 keeps every placement possible
+	return huntDensityMax*p.size - sum + 1
+}
+
+func candidates(b *Board, size int) []placement {
+	var out []placement
+	for r := 0; r < 10; r++ {
+		for c := 0; c < 10; c++ {
+			for _, vert := range []bool{false, true} {
+				p := placement{r: r, c: c, size: size, vert: vert}
+				if p.fits(b) {
+					out = append(out, p)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// GenerateBoard places the fleet using rng and the given strategy.
+// Same rng state and strategy always give the same board.
+func GenerateBoard(rng *rand.Rand, s Strategy) (Board, error) {
+	if rng == nil {
+		return Board{}, errors.New("nil random source")
+	}
+	weight, err := s.weight()
+	if err != nil {
+		return Board{}, err
+	}
+
+	var b Board
+	for _, L := range shipSizes {
+		cands := candidates(&b, L)
+		if len(cands) == 0 {
+			// can't happen with the standard fleet on 10x10 but don't loop forever
+			return Board{}, errors.New("failed to place ships")
+		}
+		total := 0
+		ws := make([]int, len(cands))
+		for i, p := range cands {
+			ws[i] = weight(&b, p)
+			total += ws[i]
+		}
+		pick := rng.IntN(total)
+		var chosen placement
+		for i, w := range ws {
+			if pick < w {
+				chosen = cands[i]
+				break
+			}
+			pick -= w
+		}
+		for _, rc := range chosen.cells() {
+			b.Cells[rc[0]][rc[1]] = 1
+		}
+	}
+	return b, nil
+}
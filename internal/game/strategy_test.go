@@ -0,0 +1,160 @@
+package game
+
+import (
+	"testing"
+
+	"battleship-zk/pkg/api"
+)
+
+// fleetFits reports whether the ship cells of b split into straight ships of
+// shipSizes, each cell used once
+func fleetFits(b Board, sizes []int) bool {
+	if len(sizes) == 0 {
+		for r := 0; r < 10; r++ {
+			for c := 0; c < 10; c++ {
+				if b.Cells[r][c] == 1 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for r := 0; r < 10; r++ {
+		for c := 0; c < 10; c++ {
+			for _, vert := range []bool{false, true} {
+				p := placement{r: r, c: c, size: sizes[0], vert: vert}
+				if !p.covered(&b) {
+					continue
+				}
+				rest := b
+				for _, rc := range p.cells() {
+					rest.Cells[rc[0]][rc[1]] = 0
+				}
+				if fleetFits(rest, sizes[1:]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// covered is the reverse of fits: every cell of p is a ship cell
+func (p placement) covered(b *Board) bool {
+	for _, rc := range p.cells() {
+		if rc[0] > 9 || rc[1] > 9 || b.Cells[rc[0]][rc[1]] != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// touching counts pairs of orthogonally or diagonally adjacent ship cells
+func touching(b Board) int {
+	n := 0
+	for r := 0; r < 10; r++ {
+		for c := 0; c < 10; c++ {
+			if b.Cells[r][c] != 1 {
+				continue
+			}
+			for _, d := range [][2]int{{0, 1}, {1, -1}, {1, 0}, {1, 1}} {
+				rr, cc := r+d[0], c+d[1]
+				if rr < 10 && cc >= 0 && cc < 10 && b.Cells[rr][cc] == 1 {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+func TestGenerateBoard(t *testing.T) {
+	for _, s := range Strategies() {
+		t.Run(string(s), func(t *testing.T) {
+			for seed := uint64(0); seed < 50; seed++ {
+				b, err := GenerateBoard(NewSeededRand(seed), s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				again, err := GenerateBoard(NewSeededRand(seed), s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if again != b {
+					t.Fatalf("seed %d gave two different boards", seed)
+				}
+				if err := b.Validate(); err != nil {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+				cells := 0
+				for _, v := range b.Flatten() {
+					cells += int(v)
+				}
+				if cells != api.ShipCells {
+					t.Fatalf("seed %d: %d ship cells, want %d", seed, cells, api.ShipCells)
+				}
+				if !fleetFits(b, shipSizes) {
+					t.Fatalf("seed %d: board is not the fleet %v without overlaps:\n%v", seed, shipSizes, b.Cells)
+				}
+			}
+		})
+	}
+
+	// different seeds do give different boards
+	a, _ := GenerateBoard(NewSeededRand(1), StrategyUniform)
+	b, _ := GenerateBoard(NewSeededRand(2), StrategyUniform)
+	if a == b {
+		t.Error("seeds 1 and 2 gave the same board")
+	}
+}
+
+// spread prefers ships that don't touch, over many boards it has fewer
+// touching cells than uniform
+func TestSpreadTouchesLess(t *testing.T) {
+	total := map[Strategy]int{}
+	for _, s := range []Strategy{StrategyUniform, StrategySpread} {
+		for seed := uint64(0); seed < 100; seed++ {
+			b, err := GenerateBoard(NewSeededRand(seed), s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a lone ship of n cells has n-1 touching pairs
+			total[s] += touching(b) - (api.ShipCells - len(shipSizes))
+		}
+	}
+	if total[StrategySpread] >= total[StrategyUniform] {
+		t.Errorf("touching pairs between ships: spread %d, uniform %d", total[StrategySpread], total[StrategyUniform])
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Strategy
+		wantErr bool
+	}{
+		{"", StrategyUniform, false},
+		{"uniform", StrategyUniform, false},
+		{"edge-avoid", StrategyEdgeAvoid, false},
+		{"spread", StrategySpread, false},
+		{"anti-hunt", StrategyAntiHunt, false},
+		{"Uniform", "", true},
+		{"random", "", true},
+		{" spread", "", true},
+	}
+	for _, tc := range tests {
+		got, err := ParseStrategy(tc.name)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("ParseStrategy(%q) = %q, %v", tc.name, got, err)
+		}
+	}
+}
+
+func TestGenerateBoardErrors(t *testing.T) {
+	if _, err := GenerateBoard(nil, StrategyUniform); err == nil {
+		t.Error("nil rng accepted")
+	}
+	if _, err := GenerateBoard(NewSeededRand(1), "random"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
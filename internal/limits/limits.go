@@ -0,0 +1,21 @@
+// Package limits is the request and proving limits of the server. It is a
+// leaf so the config file can start from the same defaults as the server.
+package limits
+
+// Limits protect the server from a client that sends too much. Every shot
+// is a full Groth16 prove, so proving gets its own bounded pool.
+type Limits struct {
+	MaxBodyBytes int64   // request bodies, larger ones get 413. 0 is the default.
+	Rate         float64 // requests per second per client ip, 0 is no limit
+	Burst        int     // requests a client may send at once
+	ProveWorkers int     // shots proved at the same time
+	ProveQueue   int     // shots waiting for a worker before prover_busy
+}
+
+var Default = Limits{
+	MaxBodyBytes: 64 << 10,
+	Rate:         5,
+	Burst:        20,
+	ProveWorkers: 1,
+	ProveQueue:   4,
+}
@@ -0,0 +1,79 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	bnmimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	bnposeidon2 "github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon2"
+)
+
+// Hash selects the field hash used for leaves, nodes and the salted root.
+// The zero value is MiMC so older secrets and payloads keep working.
+type Hash string
+
+const (
+	HashMiMC      Hash = "mimc"
+	HashPoseidon2 Hash = "poseidon2"
+)
+
+func ParseHash(name string) (Hash, error) {
+	switch Hash(name) {
+	case "", HashMiMC:
+		return HashMiMC, nil
+	case HashPoseidon2:
+		return HashPoseidon2, nil
+	}
+	return "", fmt.Errorf("unknown hash %q (want mimc or poseidon2)", name)
+}
+
+// Normalize maps the zero value to MiMC
+func (h Hash) Normalize() Hash {
+	if h == "" {
+		return HashMiMC
+	}
+	return h
+}
+
+func (h Hash) new() hash.Hash {
+	if h.Normalize() == HashPoseidon2 {
+		return bnposeidon2.NewMerkleDamgardHasher()
+	}
+	return bnmimc.NewMiMC()
+}
+
+func (h Hash) sum(xs ...*big.Int) *big.Int {
+	hh := h.new()
+	for _, x := range xs {
+		hh.Write(feBytes(x))
+	}
+	return bytesToFE(hh.Sum(nil))
+}
+
+func (h Hash) Leaf(bit uint8) *big.Int {
+	return h.sum(new(big.Int).SetUint64(uint64(bit)))
+}
+
+func (h Hash) LeafBlinded(bit uint8, nonce *big.Int) *big.Int {
+	return h.sum(new(big.Int).SetUint64(uint64(bit)), nonce)
+}
+
+func (h Hash) Node(left, right *big.Int) *big.Int {
+	return h.sum(left, right)
+}
+
+// BuildTree is BuildFixedTree with every hash taken from h, padding leaves are h.Leaf(0)
+func BuildTree(h Hash, leavesBits []uint8, nonces []*big.Int, size int) (*Tree, error) {
+	if nonces != nil && len(nonces) != len(leavesBits) {
+		return nil, errors.New("need one nonce per leaf")
+	}
+	leaf := func(i int) *big.Int {
+		if nonces != nil {
+			return h.LeafBlinded(leavesBits[i], nonces[i])
+		}
+		return h.Leaf(leavesBits[i])
+	}
+	return buildTree(len(leavesBits), leaf, size, h.Leaf(0), h.Node)
+}
@@ -36,6 +36,15 @@ func HashLeafMiMC(bit uint8) *big.Int {
 	return bytesToFE(h.Sum(nil))
 }
 
+// HashLeafBlindedMiMC hides the bit behind a per-cell nonce, so a leaf is
+// no longer one of two public constants
+func HashLeafBlindedMiMC(bit uint8, nonce *big.Int) *big.Int {
+	h := bnmimc.NewMiMC()
+	h.Write(feBytes(new(big.Int).SetUint64(uint64(bit))))
+	h.Write(feBytes(nonce))
+	return bytesToFE(h.Sum(nil))
+}
+
 func HashNodeMiMC(left, right *big.Int) *big.Int {
 	h := bnmimc.NewMiMC()
 	h.Write(feBytes(left))
@@ -48,13 +57,29 @@ type Tree struct {
 	Levels [][]*big.Int  `json:"levels"`
 }
 
-func BuildFixedTree(leavesBits []uint8, size int, padLeaf *big.Int,
+// nonces blind each leaf, pass nil for plain MiMC(bit) leaves
+func BuildFixedTree(leavesBits []uint8, nonces []*big.Int, size int, padLeaf *big.Int,
+	hashMerge func(*big.Int, *big.Int) *big.Int) (*Tree, error) {
+
+	if nonces != nil && len(nonces) != len(leavesBits) {
+		return nil, errors.New("need one nonce per leaf")
+	}
+	leaf := func(i int) *big.Int {
+		if nonces != nil {
+			return HashLeafBlindedMiMC(leavesBits[i], nonces[i])
+		}
+		return HashLeafMiMC(leavesBits[i])
+	}
+	return buildTree(len(leavesBits), leaf, size, padLeaf, hashMerge)
+}
+
+func buildTree(nLeaves int, leaf func(int) *big.Int, size int, padLeaf *big.Int,
 	hashMerge func(*big.Int, *big.Int) *big.Int) (*Tree, error) {
 
 	if size&(size-1) != 0 {
 		return nil, errors.New("size must be power of two")
 	}
-	if len(leavesBits) > size {
+	if nLeaves > size {
 		return nil, errors.New("too many leaves")
 	}
 
@@ -62,8 +87,8 @@ func BuildFixedTree(leavesBits []uint8, size int, padLeaf *big.Int,
 
 	L0 := make([]*big.Int, size)
 	for i := 0; i < size; i++ {
-		if i < len(leavesBits) {
-			L0[i] = HashLeafMiMC(leavesBits[i])
+		if i < nLeaves {
+			L0[i] = leaf(i)
 		} else {
 			L0[i] = new(big.Int).Set(padLeaf)
 		}
@@ -87,6 +112,13 @@ func BuildFixedTree(leavesBits []uint8, size int, padLeaf *big.Int,
 
 func (t *Tree) Root() *big.Int { return new(big.Int).Set(t.Levels[len(t.Levels)-1][0]) }
 
+func (t *Tree) Leaf(idx int) (*big.Int, error) {
+	if idx < 0 || idx >= len(t.Levels[0]) {
+		return nil, errors.New("idx OOB")
+	}
+	return new(big.Int).Set(t.Levels[0][idx]), nil
+}
+
 func (t *Tree) Path(idx int) (path []*big.Int, dir []uint8, err error) {
 	if idx < 0 || idx >= len(t.Levels[0]) {
 		return nil, nil, errors.New("idx OOB")
@@ -0,0 +1,101 @@
+package merkle
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// MultiProof opens several leaves at once. Siblings shared between the
+// paths, or computable from the opened leaves, are only included once.
+type MultiProof struct {
+	Depth   int        `json:"depth"`
+	Indices []int      `json:"indices"` // sorted, no duplicates
+	Nodes   []*big.Int `json:"nodes"`   // level by level, left to right
+}
+
+func (t *Tree) MultiProof(indices []int) (*MultiProof, error) {
+	if len(indices) == 0 {
+		return nil, errors.New("no indices")
+	}
+	idx := slices.Clone(indices)
+	slices.Sort(idx)
+	idx = slices.Compact(idx)
+	if idx[0] < 0 || idx[len(idx)-1] >= len(t.Levels[0]) {
+		return nil, errors.New("idx OOB")
+	}
+
+	mp := &MultiProof{Depth: t.Depth, Indices: idx}
+	known := idx
+	for level := 0; level < t.Depth; level++ {
+		for i, k := range known {
+			sib := k ^ 1
+			if k%2 == 0 && i+1 < len(known) && known[i+1] == sib {
+				continue // both children known
+			}
+			if k%2 == 1 && i > 0 && known[i-1] == sib {
+				continue
+			}
+			mp.Nodes = append(mp.Nodes, new(big.Int).Set(t.Levels[level][sib]))
+		}
+		known = parents(known)
+	}
+	return mp, nil
+}
+
+// VerifyMultiProof checks leaves (in mp.Indices order) against root
+func VerifyMultiProof(mp *MultiProof, leaves []*big.Int, root *big.Int,
+	hashMerge func(*big.Int, *big.Int) *big.Int) bool {
+
+	if mp == nil || root == nil || len(mp.Indices) == 0 || len(leaves) != len(mp.Indices) {
+		return false
+	}
+	if mp.Depth < 0 || mp.Depth > 62 || !slices.IsSorted(mp.Indices) {
+		return false
+	}
+	known := mp.Indices
+	vals := make([]*big.Int, len(leaves))
+	for i, k := range known {
+		if leaves[i] == nil || k < 0 || k>>mp.Depth != 0 || (i > 0 && known[i-1] == k) {
+			return false
+		}
+		vals[i] = leaves[i]
+	}
+
+	next := 0
+	for level := 0; level < mp.Depth; level++ {
+		var up []*big.Int
+		for i := 0; i < len(known); i++ {
+			k := known[i]
+			var left, right *big.Int
+			if k%2 == 0 && i+1 < len(known) && known[i+1] == k+1 {
+				left, right = vals[i], vals[i+1]
+				i++
+			} else {
+				if next >= len(mp.Nodes) || mp.Nodes[next] == nil {
+					return false
+				}
+				sib := mp.Nodes[next]
+				next++
+				if k%2 == 0 {
+					left, right = vals[i], sib
+				} else {
+					left, right = sib, vals[i]
+				}
+			}
+			up = append(up, hashMerge(left, right))
+		}
+		known, vals = parents(known), up
+	}
+	return next == len(mp.Nodes) && len(vals) == 1 && vals[0].Cmp(root) == 0
+}
+
+func parents(known []int) []int {
+	out := make([]int, 0, len(known))
+	for _, k := range known {
+		if len(out) == 0 || out[len(out)-1] != k/2 {
+			out = append(out, k/2)
+		}
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package merkle
+
+import (
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func leavesAt(tree *Tree, idx []int) []*big.Int {
+	out := make([]*big.Int, len(idx))
+	for i, k := range idx {
+		out[i] = new(big.Int).Set(tree.Levels[0][k])
+	}
+	return out
+}
+
+func TestMultiProofVerifies(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for seed := uint64(1); seed <= 10; seed++ {
+		tree := boardTree(t, seed)
+		for n := 1; n <= 20; n++ {
+			idx := rng.Perm(128)[:n]
+			mp, err := tree.MultiProof(idx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMultiProof(mp, leavesAt(tree, mp.Indices), tree.Root(), HashNodeMiMC) {
+				t.Fatalf("seed %d: multiproof of %v rejected", seed, idx)
+			}
+			// never more nodes than the single paths together
+			if len(mp.Nodes) > n*tree.Depth {
+				t.Fatalf("%d nodes for %d leaves", len(mp.Nodes), n)
+			}
+		}
+	}
+
+	// the whole tree needs no siblings at all
+	tree := boardTree(t, 1)
+	all := make([]int, 128)
+	for i := range all {
+		all[i] = i
+	}
+	mp, err := tree.MultiProof(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mp.Nodes) != 0 || !VerifyMultiProof(mp, leavesAt(tree, all), tree.Root(), HashNodeMiMC) {
+		t.Fatalf("opening every leaf needs %d nodes", len(mp.Nodes))
+	}
+}
+
+func TestMultiProofRejectsTampering(t *testing.T) {
+	tree := boardTree(t, 3)
+	other := boardTree(t, 4)
+	mp, err := tree.MultiProof([]int{3, 4, 17, 63, 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := leavesAt(tree, mp.Indices)
+	one := big.NewInt(1)
+
+	clone := func() (*MultiProof, []*big.Int) {
+		c := &MultiProof{Depth: mp.Depth, Indices: slices.Clone(mp.Indices)}
+		for _, n := range mp.Nodes {
+			c.Nodes = append(c.Nodes, new(big.Int).Set(n))
+		}
+		return c, leavesAt(tree, mp.Indices)
+	}
+	tests := []struct {
+		name string
+		edit func(mp *MultiProof, leaves []*big.Int) *big.Int // returns the root to check
+	}{
+		{"leaf changed", func(_ *MultiProof, l []*big.Int) *big.Int { l[2].Add(l[2], one); return tree.Root() }},
+		{"leaves swapped", func(_ *MultiProof, l []*big.Int) *big.Int { l[0], l[1] = l[1], l[0]; return tree.Root() }},
+		{"node changed", func(m *MultiProof, _ []*big.Int) *big.Int { m.Nodes[3].Add(m.Nodes[3], one); return tree.Root() }},
+		{"node dropped", func(m *MultiProof, _ []*big.Int) *big.Int { m.Nodes = m.Nodes[1:]; return tree.Root() }},
+		{"node added", func(m *MultiProof, _ []*big.Int) *big.Int { m.Nodes = append(m.Nodes, one); return tree.Root() }},
+		{"index moved", func(m *MultiProof, _ []*big.Int) *big.Int { m.Indices[2] = 18; return tree.Root() }},
+		{"indices unsorted", func(m *MultiProof, _ []*big.Int) *big.Int {
+			m.Indices[0], m.Indices[1] = m.Indices[1], m.Indices[0]
+			return tree.Root()
+		}},
+		{"duplicate index", func(m *MultiProof, _ []*big.Int) *big.Int { m.Indices[1] = m.Indices[0]; return tree.Root() }},
+		{"index out of range", func(m *MultiProof, _ []*big.Int) *big.Int { m.Indices[4] = 128; return tree.Root() }},
+		{"depth changed", func(m *MultiProof, _ []*big.Int) *big.Int { m.Depth--; return tree.Root() }},
+		{"root of another board", func(_ *MultiProof, _ []*big.Int) *big.Int { return other.Root() }},
+	}
+	if !VerifyMultiProof(mp, leaves, tree.Root(), HashNodeMiMC) {
+		t.Fatal("untampered multiproof rejected")
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, l := clone()
+			root := tc.edit(m, l)
+			if VerifyMultiProof(m, l, root, HashNodeMiMC) {
+				t.Fatal("tampered multiproof accepted")
+			}
+		})
+	}
+	if VerifyMultiProof(mp, leaves[:4], tree.Root(), HashNodeMiMC) {
+		t.Error("multiproof accepted with a leaf missing")
+	}
+	if _, err := tree.MultiProof([]int{5, 128}); err == nil {
+		t.Error("MultiProof accepted an index out of range")
+	}
+}
@@ -0,0 +1,95 @@
+package merkle
+
+import (
+	"errors"
+	"math/big"
+)
+
+// SparseTree is a Merkle tree where untouched subtrees hash to precomputed
+// "empty" values, so only set leaves and their paths are stored. Useful for
+// depths where a full Tree would not fit in memory.
+type SparseTree struct {
+	depth int
+	zeros []*big.Int // zeros[l] is the root of an empty subtree of height l
+	nodes map[sparseKey]*big.Int
+	merge func(*big.Int, *big.Int) *big.Int
+}
+
+type sparseKey struct{ level, idx int }
+
+func NewSparseTree(depth int, emptyLeaf *big.Int,
+	hashMerge func(*big.Int, *big.Int) *big.Int) (*SparseTree, error) {
+
+	if depth < 1 || depth > 62 {
+		return nil, errors.New("depth must be in 1..62")
+	}
+	if emptyLeaf == nil {
+		return nil, errors.New("nil empty leaf")
+	}
+	zeros := make([]*big.Int, depth+1)
+	zeros[0] = new(big.Int).Set(emptyLeaf)
+	for l := 1; l <= depth; l++ {
+		zeros[l] = hashMerge(zeros[l-1], zeros[l-1])
+	}
+	return &SparseTree{depth: depth, zeros: zeros, nodes: make(map[sparseKey]*big.Int), merge: hashMerge}, nil
+}
+
+func (s *SparseTree) Depth() int { return s.depth }
+
+func (s *SparseTree) node(level, idx int) *big.Int {
+	if v, ok := s.nodes[sparseKey{level, idx}]; ok {
+		return v
+	}
+	return s.zeros[level]
+}
+
+func (s *SparseTree) setNode(level, idx int, v *big.Int) {
+	if v.Cmp(s.zeros[level]) == 0 {
+		delete(s.nodes, sparseKey{level, idx})
+		return
+	}
+	s.nodes[sparseKey{level, idx}] = v
+}
+
+// Set writes a leaf and updates its path, O(depth). Setting the empty leaf frees the path.
+func (s *SparseTree) Set(idx int, leaf *big.Int) error {
+	if idx < 0 || idx>>s.depth != 0 {
+		return errors.New("idx OOB")
+	}
+	if leaf == nil {
+		return errors.New("nil leaf")
+	}
+	s.setNode(0, idx, new(big.Int).Set(leaf))
+	cur := idx
+	for level := 0; level < s.depth; level++ {
+		left := cur &^ 1
+		s.setNode(level+1, cur/2, s.merge(s.node(level, left), s.node(level, left+1)))
+		cur /= 2
+	}
+	return nil
+}
+
+func (s *SparseTree) Get(idx int) (*big.Int, error) {
+	if idx < 0 || idx>>s.depth != 0 {
+		return nil, errors.New("idx OOB")
+	}
+	return new(big.Int).Set(s.node(0, idx)), nil
+}
+
+func (s *SparseTree) Root() *big.Int { return new(big.Int).Set(s.node(s.depth, 0)) }
+
+// Path has the same shape as Tree.Path and checks with Hash.VerifyPath
+func (s *SparseTree) Path(idx int) (path []*big.Int, dir []uint8, err error) {
+	if idx < 0 || idx>>s.depth != 0 {
+		return nil, nil, errors.New("idx OOB")
+	}
+	path = make([]*big.Int, 0, s.depth)
+	dir = make([]uint8, 0, s.depth)
+	cur := idx
+	for level := 0; level < s.depth; level++ {
+		path = append(path, new(big.Int).Set(s.node(level, cur^1)))
+		dir = append(dir, uint8(cur&1))
+		cur /= 2
+	}
+	return path, dir, nil
+}
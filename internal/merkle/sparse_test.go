@@ -0,0 +1,59 @@
+package merkle
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSparseTreeMatchesFixedTree(t *testing.T) {
+	for seed := uint64(1); seed <= 5; seed++ {
+		bits, nonces := randomBoard(t, seed)
+		want := fixedTree(t, bits, nonces)
+
+		s, err := NewSparseTree(7, HashLeafMiMC(0), HashNodeMiMC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range bits {
+			if err := s.Set(i, want.Levels[0][i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if s.Root().Cmp(want.Root()) != 0 {
+			t.Fatalf("seed %d: sparse root differs from the fixed tree", seed)
+		}
+		for i := 0; i < 128; i++ {
+			path, dir, err := s.Path(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantPath, wantDir, _ := want.Path(i)
+			for l := range wantPath {
+				if path[l].Cmp(wantPath[l]) != 0 || dir[l] != wantDir[l] {
+					t.Fatalf("seed %d: path of leaf %d differs at level %d", seed, i, l)
+				}
+			}
+		}
+	}
+}
+
+func TestSparseTreeEmptyLeafFreesPath(t *testing.T) {
+	empty := HashLeafMiMC(0)
+	s, err := NewSparseTree(7, empty, HashNodeMiMC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := s.Root()
+	if err := s.Set(42, big.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Root().Cmp(root) == 0 {
+		t.Fatal("setting a leaf kept the empty root")
+	}
+	if err := s.Set(42, empty); err != nil {
+		t.Fatal(err)
+	}
+	if s.Root().Cmp(root) != 0 || len(s.nodes) != 0 {
+		t.Fatalf("resetting the leaf left %d nodes", len(s.nodes))
+	}
+}
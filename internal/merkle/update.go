@@ -0,0 +1,41 @@
+package merkle
+
+import (
+	"errors"
+	"math/big"
+)
+
+// BuildFromLeaves is BuildFixedTree for leaves that are already hashed, or any
+// other field values. Missing leaves up to size are padLeaf.
+func BuildFromLeaves(leaves []*big.Int, size int, padLeaf *big.Int,
+	hashMerge func(*big.Int, *big.Int) *big.Int) (*Tree, error) {
+
+	for _, l := range leaves {
+		if l == nil {
+			return nil, errors.New("nil leaf")
+		}
+	}
+	leaf := func(i int) *big.Int { return new(big.Int).Set(leaves[i]) }
+	return buildTree(len(leaves), leaf, size, padLeaf, hashMerge)
+}
+
+// Update replaces leaf idx and rehashes only its path to the root, O(depth).
+// hashMerge must be the one the tree was built with.
+func (t *Tree) Update(idx int, leaf *big.Int,
+	hashMerge func(*big.Int, *big.Int) *big.Int) error {
+
+	if idx < 0 || idx >= len(t.Levels[0]) {
+		return errors.New("idx OOB")
+	}
+	if leaf == nil {
+		return errors.New("nil leaf")
+	}
+	t.Levels[0][idx] = new(big.Int).Set(leaf)
+	cur := idx
+	for level := 0; level < t.Depth; level++ {
+		left := cur &^ 1
+		t.Levels[level+1][cur/2] = hashMerge(t.Levels[level][left], t.Levels[level][left+1])
+		cur /= 2
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package merkle
+
+import (
+	"math/big"
+	"testing"
+
+	"battleship-zk/internal/game"
+)
+
+// randomBoard is a placed board and a nonce per cell, all from seed
+func randomBoard(t *testing.T, seed uint64) ([]uint8, []*big.Int) {
+	t.Helper()
+	rng := game.NewSeededRand(seed)
+	b, err := game.GenerateBoard(rng, game.StrategyUniform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonces := make([]*big.Int, 100)
+	for i := range nonces {
+		nonces[i] = new(big.Int).SetUint64(rng.Uint64())
+	}
+	return b.Flatten(), nonces
+}
+
+func fixedTree(t *testing.T, bits []uint8, nonces []*big.Int) *Tree {
+	t.Helper()
+	tree, err := BuildFixedTree(bits, nonces, 128, HashLeafMiMC(0), HashNodeMiMC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func boardTree(t *testing.T, seed uint64) *Tree {
+	t.Helper()
+	bits, nonces := randomBoard(t, seed)
+	return fixedTree(t, bits, nonces)
+}
+
+// sameTree compares every node, so roots and all paths agree
+func sameTree(t *testing.T, got, want *Tree) {
+	t.Helper()
+	if got.Depth != want.Depth || len(got.Levels) != len(want.Levels) {
+		t.Fatalf("depth %d, want %d", got.Depth, want.Depth)
+	}
+	for l := range want.Levels {
+		for i := range want.Levels[l] {
+			if got.Levels[l][i].Cmp(want.Levels[l][i]) != 0 {
+				t.Fatalf("node %d at level %d differs", i, l)
+			}
+		}
+	}
+}
+
+func TestBuildFromLeavesMatchesFixedTree(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		bits, nonces := randomBoard(t, seed)
+		want := fixedTree(t, bits, nonces)
+		got, err := BuildFromLeaves(want.Levels[0][:100], 128, HashLeafMiMC(0), HashNodeMiMC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sameTree(t, got, want)
+
+		// BuildTree with the MiMC Hash is the same tree
+		got, err = BuildTree(HashMiMC, bits, nonces, 128)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sameTree(t, got, want)
+	}
+}
+
+func TestUpdateMatchesFixedTree(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		fromBits, fromNonces := randomBoard(t, seed)
+		toBits, toNonces := randomBoard(t, seed+100)
+		tree := fixedTree(t, fromBits, fromNonces)
+
+		for i := range toBits {
+			if err := tree.Update(i, HashLeafBlindedMiMC(toBits[i], toNonces[i]), HashNodeMiMC); err != nil {
+				t.Fatal(err)
+			}
+			// every step is a valid tree: the updated leaf opens against the new root
+			path, dir, err := tree.Path(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := HashMiMC.VerifyPath(HashLeafBlindedMiMC(toBits[i], toNonces[i]), i, path, dir, tree.Root()); err != nil {
+				t.Fatalf("seed %d, leaf %d: %v", seed, i, err)
+			}
+		}
+		sameTree(t, tree, fixedTree(t, toBits, toNonces))
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	bits, nonces := randomBoard(t, 1)
+	tree := fixedTree(t, bits, nonces)
+	for _, idx := range []int{-1, 128} {
+		if tree.Update(idx, big.NewInt(1), HashNodeMiMC) == nil {
+			t.Errorf("Update(%d) accepted", idx)
+		}
+	}
+	if tree.Update(0, nil, HashNodeMiMC) == nil {
+		t.Errorf("Update with a nil leaf accepted")
+	}
+	if _, err := BuildFromLeaves([]*big.Int{big.NewInt(1), nil}, 4, big.NewInt(0), HashNodeMiMC); err == nil {
+		t.Errorf("BuildFromLeaves with a nil leaf accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrPathMismatch = errors.New("merkle path does not lead to the root")
+
+// VerifyPath is the native twin of the path walk in zk.ShotCircuit: dir bits
+// must be boolean and equal the bits of idx, each level hashes
+// (Select(dir, sib, cur), Select(dir, cur, sib)) with h.
+func (h Hash) VerifyPath(leaf *big.Int, idx int, path []*big.Int, dir []uint8, root *big.Int) error {
+	if root == nil {
+		return errors.New("nil root")
+	}
+	treeRoot, err := h.walkPath(leaf, idx, path, dir)
+	if err != nil {
+		return err
+	}
+	if treeRoot.Cmp(root) != 0 {
+		return ErrPathMismatch
+	}
+	return nil
+}
+
+// VerifySaltedPath also applies the final H(salt, treeRoot) step, so it
+// checks exactly what the circuit asserts against the public root
+func (h Hash) VerifySaltedPath(leaf *big.Int, idx int, path []*big.Int, dir []uint8, salt, saltedRoot *big.Int) error {
+	if salt == nil || saltedRoot == nil {
+		return errors.New("nil salt or root")
+	}
+	treeRoot, err := h.walkPath(leaf, idx, path, dir)
+	if err != nil {
+		return err
+	}
+	if h.Node(salt, treeRoot).Cmp(saltedRoot) != 0 {
+		return ErrPathMismatch
+	}
+	return nil
+}
+
+func (h Hash) walkPath(leaf *big.Int, idx int, path []*big.Int, dir []uint8) (*big.Int, error) {
+	if leaf == nil {
+		return nil, errors.New("nil leaf")
+	}
+	if len(path) != len(dir) || len(path) == 0 || len(path) > 62 {
+		return nil, fmt.Errorf("bad path length %d/%d", len(path), len(dir))
+	}
+	if idx < 0 || idx>>len(path) != 0 {
+		return nil, fmt.Errorf("index %d does not fit in %d bits", idx, len(path))
+	}
+	cur := leaf
+	for i := range path {
+		if dir[i] > 1 {
+			return nil, fmt.Errorf("dir[%d] is not boolean", i)
+		}
+		if dir[i] != uint8((idx>>i)&1) {
+			return nil, fmt.Errorf("dir[%d] does not match index %d", i, idx)
+		}
+		if path[i] == nil {
+			return nil, fmt.Errorf("nil sibling at level %d", i)
+		}
+		left, right := cur, path[i]
+		if dir[i] == 1 {
+			left, right = path[i], cur
+		}
+		cur = h.Node(left, right)
+	}
+	return cur, nil
+}
@@ -0,0 +1,72 @@
+// Package metrics holds the process wide Prometheus collectors of the
+// proving and verifying code. Per server game metrics are registered by
+// internal/server on its own registry and served next to these.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry has everything below plus the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+// proving takes seconds to minutes depending on the machine and hash
+var proveBuckets = prometheus.ExponentialBuckets(0.1, 2, 12) // 0.1s .. ~3m
+
+var (
+	// zk
+	CompileSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "battleship_circuit_compile_seconds",
+		Help:    "Time to compile the shot circuit.",
+		Buckets: proveBuckets,
+	}, []string{"hash"})
+	ProveSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "battleship_prove_seconds",
+		Help:    "Groth16 prove time of one shot proof.",
+		Buckets: proveBuckets,
+	}, []string{"hash"})
+	VerifySeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "battleship_verify_seconds",
+		Help:    "Groth16 verify time of one shot proof.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 12), // 1ms .. ~2s
+	}, []string{"hash", "result"})
+	Constraints = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "battleship_circuit_constraints",
+		Help: "Constraint count of the compiled shot circuit.",
+	}, []string{"hash"})
+	ProofsVerified = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "battleship_proofs_verified_total",
+		Help: "Shot proofs checked, result is hit, miss or invalid.",
+	}, []string{"result"})
+
+	// app
+	ProofsGenerated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "battleship_proofs_generated_total",
+		Help: "Shot proofs produced, by answer.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		CompileSeconds, ProveSeconds, VerifySeconds, Constraints,
+		ProofsGenerated, ProofsVerified,
+	)
+}
+
+// Since observes the seconds since t0 on o, for defer
+func Since(o prometheus.Observer, t0 time.Time) {
+	o.Observe(time.Since(t0).Seconds())
+}
+
+// HitMiss is the result label of a shot answer
+func HitMiss(bit uint8) string {
+	if bit == 1 {
+		return "hit"
+	}
+	return "miss"
+}
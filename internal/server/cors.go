@@ -0,0 +1,204 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"battleship-zk/pkg/api"
+)
+
+// CORSPolicy says which web pages other than our own may use the API. The
+// zero value only allows same-origin requests, our own web UI.
+type CORSPolicy struct {
+	// origins like "https://a.example:8080", "*" allows any page
+	Origins []string
+	// methods allowed cross-origin per route, routes not listed here use
+	// DefaultRouteMethods
+	Methods map[string][]string
+	// also allow the origin of the registered peer, whose web UI may read
+	// our state. Only on PeerRoutes and only with GET.
+	AllowPeer bool
+	// host names our own web UI is served under, besides localhost, IP
+	// addresses and the host of PublicURL. A page under any other name is
+	// not same-origin even if Origin matches Host, which is what a DNS
+	// rebinding page sends.
+	Hosts []string
+}
+
+// DefaultRouteMethods are the methods of every /v1 route
+var DefaultRouteMethods = map[string][]string{
+	"/v1/init":         {http.MethodPost},
+	"/v1/commit":       {http.MethodPost},
+	"/v1/shoot":        {http.MethodPost},
+	"/v1/verify":       {http.MethodPost},
+	"/v1/fire":         {http.MethodPost},
+	"/v1/status":       {http.MethodGet},
+	"/v1/peer":         {http.MethodPut},
+	"/v1/openapi.json": {http.MethodGet},
+	"/v1/ws":           {http.MethodGet},
+}
+
+// PeerRoutes are the read-only routes the peer's origin may use with
+// AllowPeer. Never anything that commits, registers a peer or fires.
+var PeerRoutes = []string{"/v1/status", "/v1/openapi.json", "/v1/ws"}
+
+const corsMaxAge = 600 // seconds a preflight answer is cached
+
+var errOrigin = opErr(api.CodeForbiddenOrigin, "origin not allowed")
+
+func (p CORSPolicy) methods(path string) []string {
+	if m, ok := p.Methods[path]; ok {
+		return m
+	}
+	return DefaultRouteMethods[path]
+}
+
+func originOf(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// ownHost is a Host header our own web UI can be served under
+func (s *Server) ownHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "localhost" || net.ParseIP(host) != nil {
+		return true
+	}
+	if u, err := url.Parse(s.PublicURL); err == nil && s.PublicURL != "" && strings.EqualFold(u.Hostname(), host) {
+		return true
+	}
+	return slices.ContainsFunc(s.CORS.Hosts, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), host) })
+}
+
+// sameOrigin is a request from the page we served ourselves
+func (s *Server) sameOrigin(r *http.Request, origin string) bool {
+	if s.PublicURL != "" && originOf(s.PublicURL) == origin {
+		return true
+	}
+	if !s.ownHost(r.Host) {
+		return false
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return strings.ToLower(scheme+"://"+r.Host) == origin
+}
+
+// listedOrigin is a page named in CORS.Origins
+func (s *Server) listedOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range s.CORS.Origins {
+		if o == "*" || strings.ToLower(strings.TrimRight(o, "/")) == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// peerOrigin is the web UI of the registered peer, allowed with AllowPeer
+func (s *Server) peerOrigin(origin string) bool {
+	if !s.CORS.AllowPeer {
+		return false
+	}
+	s.mu.RLock()
+	peer := s.peer
+	s.mu.RUnlock()
+	return peer != nil && originOf(peer.BaseURL) == strings.ToLower(origin)
+}
+
+// corsMethods are the methods a cross-origin page may use on path, none if
+// the policy doesn't allow it there
+func (s *Server) corsMethods(origin, path string) []string {
+	if s.listedOrigin(origin) {
+		return s.CORS.methods(path)
+	}
+	if s.peerOrigin(origin) && slices.Contains(PeerRoutes, path) {
+		return slices.DeleteFunc(slices.Clone(s.CORS.methods(path)), func(m string) bool { return m != http.MethodGet })
+	}
+	return nil
+}
+
+func unsafeMethod(m string) bool {
+	return m != http.MethodGet && m != http.MethodHead && m != http.MethodOptions
+}
+
+// WithCORS applies s.CORS to next. Requests without an Origin header (curl,
+// Go programs, the peer's server) pass. A state-changing request from a
+// page we don't allow is refused with 403 before it reaches a handler,
+// which is the CSRF protection: browsers always send Origin on those, and
+// Sec-Fetch-Site when they leave it out.
+func (s *Server) WithCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			if unsafeMethod(r.Method) && r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+				writeErr(w, errOrigin)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if s.sameOrigin(r, strings.ToLower(origin)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		methods := s.corsMethods(origin, r.URL.Path)
+		allowed := len(methods) > 0
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			// preflight
+			if !allowed {
+				writeErr(w, errOrigin)
+				return
+			}
+			if !slices.Contains(methods, r.Header.Get("Access-Control-Request-Method")) {
+				writeErr(w, errMethod)
+				return
+			}
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			h.Set("Access-Control-Allow-Headers", "Content-Type")
+			h.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if !allowed || !slices.Contains(methods, r.Method) {
+			if unsafeMethod(r.Method) {
+				writeErr(w, errOrigin)
+				return
+			}
+			// reads go through without CORS headers, the browser keeps the
+			// answer from the page
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkWSOrigin is the /v1/ws upgrader's origin check, the same policy as
+// the rest of the API
+func (s *Server) checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || s.sameOrigin(r, strings.ToLower(origin)) {
+		return true
+	}
+	return slices.Contains(s.corsMethods(origin, "/v1/ws"), http.MethodGet)
+}
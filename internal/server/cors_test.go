@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"battleship-zk/pkg/api"
+)
+
+func TestCORS(t *testing.T) {
+	s := newTestServer(t)
+	s.CORS = CORSPolicy{
+		Origins:   []string{"https://ui.example"},
+		Methods:   map[string][]string{"/v1/status": {http.MethodGet}, "/v1/commit": nil},
+		AllowPeer: true,
+		Hosts:     []string{"game.example"},
+	}
+	s.peer = &api.Peer{BaseURL: "https://peer.example:8443"}
+	mux := http.NewServeMux()
+	s.Routes(mux)
+	h := s.WithCORS(mux)
+
+	const (
+		ui    = "https://ui.example"
+		peer  = "https://peer.example:8443"
+		other = "https://evil.example"
+	)
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		host      string // default localhost:8080
+		header    map[string]string
+		status    int
+		allowFrom string // expected Access-Control-Allow-Origin
+	}{
+		{name: "preflight allowed", method: "OPTIONS", path: "/v1/fire",
+			header: map[string]string{"Origin": ui, "Access-Control-Request-Method": "POST"}, status: 204, allowFrom: ui},
+		{name: "preflight from another page", method: "OPTIONS", path: "/v1/fire",
+			header: map[string]string{"Origin": other, "Access-Control-Request-Method": "POST"}, status: 403},
+		{name: "preflight method not allowed", method: "OPTIONS", path: "/v1/status",
+			header: map[string]string{"Origin": ui, "Access-Control-Request-Method": "DELETE"}, status: 405},
+		{name: "preflight on a route with no methods", method: "OPTIONS", path: "/v1/commit",
+			header: map[string]string{"Origin": ui, "Access-Control-Request-Method": "POST"}, status: 403},
+		{name: "cross-site POST without Origin", method: "POST", path: "/v1/init",
+			header: map[string]string{"Sec-Fetch-Site": "cross-site"}, status: 403},
+		{name: "POST without Origin from curl", method: "POST", path: "/v1/init", status: 200},
+		{name: "POST from another page", method: "POST", path: "/v1/init",
+			header: map[string]string{"Origin": other}, status: 403},
+		{name: "GET from another page has no CORS headers", method: "GET", path: "/v1/status",
+			header: map[string]string{"Origin": other}, status: 200},
+		{name: "GET from an allowed page", method: "GET", path: "/v1/status",
+			header: map[string]string{"Origin": ui}, status: 200, allowFrom: ui},
+		{name: "same origin POST", method: "POST", path: "/v1/init",
+			header: map[string]string{"Origin": "http://localhost:8080"}, status: 200},
+		{name: "same origin on a listed host", method: "POST", path: "/v1/init", host: "game.example:8080",
+			header: map[string]string{"Origin": "http://game.example:8080"}, status: 200},
+		{name: "same origin on an ip", method: "POST", path: "/v1/init", host: "192.0.2.7:8080",
+			header: map[string]string{"Origin": "http://192.0.2.7:8080"}, status: 200},
+		{name: "DNS rebinding", method: "POST", path: "/v1/init", host: "evil.example:8080",
+			header: map[string]string{"Origin": "http://evil.example:8080"}, status: 403},
+
+		// the peer's UI reads, it never changes our game
+		{name: "peer reads status", method: "GET", path: "/v1/status",
+			header: map[string]string{"Origin": peer}, status: 200, allowFrom: peer},
+		{name: "peer preflight on status", method: "OPTIONS", path: "/v1/status",
+			header: map[string]string{"Origin": peer, "Access-Control-Request-Method": "GET"}, status: 204, allowFrom: peer},
+		{name: "peer preflight on fire", method: "OPTIONS", path: "/v1/fire",
+			header: map[string]string{"Origin": peer, "Access-Control-Request-Method": "POST"}, status: 403},
+		{name: "peer commits", method: "POST", path: "/v1/commit",
+			header: map[string]string{"Origin": peer}, status: 403},
+		{name: "peer registers a peer", method: "PUT", path: "/v1/peer",
+			header: map[string]string{"Origin": peer}, status: 403},
+		{name: "peer fires", method: "POST", path: "/v1/fire",
+			header: map[string]string{"Origin": peer}, status: 403},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+			r.Host = "localhost:8080"
+			if tc.host != "" {
+				r.Host = tc.host
+			}
+			for k, v := range tc.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Errorf("status %d, want %d: %s", w.Code, tc.status, w.Body)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.allowFrom {
+				t.Errorf("Access-Control-Allow-Origin %q, want %q", got, tc.allowFrom)
+			}
+		})
+	}
+}
+
+func TestCheckWSOrigin(t *testing.T) {
+	s := newTestServer(t)
+	s.CORS = CORSPolicy{AllowPeer: true}
+	s.peer = &api.Peer{BaseURL: "https://peer.example:8443"}
+	tests := []struct {
+		host, origin string
+		want         bool
+	}{
+		{"localhost:8080", "", true},
+		{"localhost:8080", "http://localhost:8080", true},
+		{"localhost:8080", "https://peer.example:8443", true},
+		{"localhost:8080", "https://evil.example", false},
+		{"evil.example:8080", "http://evil.example:8080", false},
+	}
+	for _, tc := range tests {
+		r := httptest.NewRequest("GET", "/v1/ws", nil)
+		r.Host = tc.host
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if got := s.checkWSOrigin(r); got != tc.want {
+			t.Errorf("Host %s Origin %q: %v, want %v", tc.host, tc.origin, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"battleship-zk/pkg/api"
+)
+
+// hub fans game events out to the /v1/ws connections. publish never blocks,
+// it is called with s.mu held.
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan api.Event]struct{}
+}
+
+func (h *hub) subscribe() chan api.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[chan api.Event]struct{})
+	}
+	ch := make(chan api.Event, 32)
+	h.subs[ch] = struct{}{}
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan api.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+func (h *hub) publish(ev api.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			// too slow, drop it, the client reconnects and gets a fresh status
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// closeAll ends every subscription, the /v1/ws handlers then return
+func (h *hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+func turnEvent(t *turnState) api.Event {
+	return api.Event{Type: api.EventTurn, Turn: &api.TurnState{MyTurn: t.MyTurn, Ready: t.Ready, Decided: t.Decided}}
+}
+
+func apiGame(g *gameState) *api.GameState {
+	return &api.GameState{HitsTaken: g.HitsTaken, HitsDealt: g.HitsDealt, Over: g.Over, Winner: g.Winner}
+}
+
+const (
+	wsWriteWait = 10 * time.Second
+	wsPingEvery = 30 * time.Second
+)
+
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	up := websocket.Upgrader{CheckOrigin: s.checkWSOrigin}
+	conn, err := up.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already replied
+	}
+	defer conn.Close()
+
+	// subscribe before the snapshot so nothing falls in between
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	st := s.statusPayload()
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := conn.WriteJSON(api.Event{Type: api.EventStatus, Status: &st}); err != nil {
+		return
+	}
+
+	// we don't expect messages, reading is only for pongs and close. The
+	// deadline replaces whatever http.Server.ReadTimeout left on the conn.
+	_ = conn.SetReadDeadline(time.Now().Add(wsPingEvery + wsWriteWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPingEvery + wsWriteWait))
+	})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingEvery)
+	defer ping.Stop()
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
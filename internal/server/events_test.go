@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+// httpPlayer is one player's plain HTTP server
+type httpPlayer struct {
+	s *Server
+	c *client.Client
+}
+
+func newHTTPPlayer(t *testing.T, keysDir string) *httpPlayer {
+	t.Helper()
+	s := newTestServer(t)
+	s.KeysDir = keysDir
+	mux := http.NewServeMux()
+	s.Routes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	s.PublicURL = srv.URL
+	return &httpPlayer{s: s, c: client.New(srv.URL)}
+}
+
+// nextEvent skips to the first event of type want on ch
+func nextEvent(t *testing.T, ch <-chan api.Event, want api.EventType) api.Event {
+	t.Helper()
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("event stream closed before a %s event", want)
+			}
+			if ev.Type == want {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
+
+// every event type reaches a /v1/ws subscriber: turn once both are
+// committed and registered, defense on the defender after shoot, verified
+// and game_over on the attacker after verify
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys := t.TempDir()
+	a, b := newHTTPPlayer(t, keys), newHTTPPlayer(t, keys)
+
+	events := map[*httpPlayer]<-chan api.Event{}
+	boards := map[*httpPlayer]*api.Board{}
+	seed := uint64(5)
+	for _, p := range []*httpPlayer{a, b} {
+		ch, err := p.c.Events(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev := <-ch; ev.Type != api.EventStatus || ev.Status == nil {
+			t.Fatalf("first event %+v, want the status", ev)
+		}
+		events[p] = ch
+		if boards[p], err = p.c.Init(ctx, api.InitRequest{Seed: &seed}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.c.Commit(ctx, api.CommitRequest{Board: *boards[p]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pair := range [][2]*httpPlayer{{a, b}, {b, a}} {
+		if _, err := pair[0].c.SetPeer(ctx, api.Peer{BaseURL: pair[1].s.PublicURL}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var attacker, defender *httpPlayer
+	for _, p := range []*httpPlayer{a, b} {
+		ev := nextEvent(t, events[p], api.EventTurn)
+		for !ev.Turn.Decided {
+			ev = nextEvent(t, events[p], api.EventTurn)
+		}
+		if ev.Turn.MyTurn == "me" {
+			attacker = p
+		}
+	}
+	if attacker == nil {
+		t.Fatal("neither server got the first turn")
+	}
+	defender = a
+	if attacker == a {
+		defender = b
+	}
+
+	// the next hit wins
+	attacker.s.mu.Lock()
+	attacker.s.game = &gameState{HitsDealt: api.ShipCells - 1}
+	attacker.s.mu.Unlock()
+	row, col := -1, -1
+	for r := 0; r < 10 && row < 0; r++ {
+		for c := 0; c < 10; c++ {
+			if boards[defender].Cells[r][c] == 1 {
+				row, col = r, c
+				break
+			}
+		}
+	}
+
+	shot, err := defender.c.Shoot(ctx, row, col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := nextEvent(t, events[defender], api.EventDefense)
+	if ev.Shot == nil || ev.Shot.Row != row || ev.Shot.Col != col || ev.Shot.Bit != 1 || ev.Game == nil || ev.Game.HitsTaken != 1 {
+		t.Errorf("defense event %+v", ev)
+	}
+	if ev := nextEvent(t, events[defender], api.EventTurn); ev.Turn.MyTurn != "me" {
+		t.Errorf("defender's turn event %+v after the shot", ev.Turn)
+	}
+
+	res, err := attacker.c.Verify(ctx, api.VerifyRequest{RootHex: shot.RootHex, Payload: shot.Payload, VKB64: shot.VKB64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.Hit != 1 {
+		t.Fatalf("verify %+v", res)
+	}
+	if ev := nextEvent(t, events[attacker], api.EventTurn); ev.Turn.MyTurn != "opponent" {
+		t.Errorf("attacker's turn event %+v after verify", ev.Turn)
+	}
+	if ev := nextEvent(t, events[attacker], api.EventGameOver); ev.Game == nil || !ev.Game.Over || ev.Game.Winner != "me" {
+		t.Errorf("game_over event %+v", ev)
+	}
+	ev = nextEvent(t, events[attacker], api.EventVerified)
+	if ev.Shot == nil || ev.Shot.Row != row || ev.Shot.Col != col || ev.Shot.Bit != 1 || ev.Game == nil || ev.Game.HitsDealt != api.ShipCells {
+		t.Errorf("verified event %+v", ev)
+	}
+}
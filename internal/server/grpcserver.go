@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"battleship-zk/internal/codec"
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/zk"
+	"battleship-zk/pkg/api"
+	pb "battleship-zk/pkg/battleshipv1"
+)
+
+// grpcService serves the same game state as the HTTP handlers
+type grpcService struct {
+	pb.UnimplementedBattleshipServer
+	s *Server
+}
+
+// RegisterGRPC adds the Battleship service backed by s to g
+func (s *Server) RegisterGRPC(g *grpc.Server) {
+	pb.RegisterBattleshipServer(g, &grpcService{s: s})
+}
+
+func grpcErr(err error) error {
+	var oe *opError
+	if !errors.As(err, &oe) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	msg := oe.body.Message
+	switch oe.body.Code {
+	case api.CodeBadRequest, api.CodeInvalidBoard, api.CodeBadProof:
+		return status.Error(codes.InvalidArgument, msg)
+	case api.CodeNotCommitted, api.CodeNotYourTurn, api.CodeGameOver:
+		return status.Error(codes.FailedPrecondition, msg)
+	case api.CodeCellTargeted:
+		return status.Error(codes.AlreadyExists, msg)
+	case api.CodeUnauthenticated:
+		return status.Error(codes.Unauthenticated, msg)
+	case api.CodeForbiddenOrigin:
+		return status.Error(codes.PermissionDenied, msg)
+	case api.CodeRateLimited, api.CodeProverBusy:
+		return status.Error(codes.ResourceExhausted, msg)
+	case api.CodeTooLarge:
+		return status.Error(codes.InvalidArgument, msg)
+	case api.CodePeerUnavailable, api.CodeUnavailable:
+		return status.Error(codes.Unavailable, msg)
+	case api.CodeMethodNotAllowed:
+		return status.Error(codes.Unimplemented, msg)
+	}
+	return status.Error(codes.Internal, msg)
+}
+
+func boardToPB(b game.Board) *pb.Board {
+	cells := make([]uint32, 0, 100)
+	for _, v := range b.Flatten() {
+		cells = append(cells, uint32(v))
+	}
+	return &pb.Board{Cells: cells}
+}
+
+func boardFromPB(p *pb.Board) (game.Board, error) {
+	var b game.Board
+	if len(p.GetCells()) != 100 {
+		return b, fmt.Errorf("board must have 100 cells, got %d", len(p.GetCells()))
+	}
+	for i, v := range p.GetCells() {
+		if v > 1 {
+			return b, errors.New("board has non-binary cell")
+		}
+		b.Cells[i/10][i%10] = uint8(v)
+	}
+	return b, nil
+}
+
+func proofFromPB(p *pb.ShotProof) (*codec.ShotProofPayload, error) {
+	pub := p.GetPublic()
+	if pub == nil {
+		return nil, errors.New("proof payload missing public values")
+	}
+	if pub.GetHit() > 1 || pub.GetRow() > 9 || pub.GetCol() > 9 {
+		return nil, errors.New("proof payload: public hit/row/col out of range")
+	}
+	h, err := merkle.ParseHash(pub.GetHash())
+	if err != nil {
+		return nil, err
+	}
+	root, err := codec.ParseFieldHex(pub.GetRoot())
+	if err != nil {
+		return nil, fmt.Errorf("proof payload root: %w", err)
+	}
+	if err := zk.CheckProofBytes(p.GetProof()); err != nil {
+		return nil, err
+	}
+	return &codec.ShotProofPayload{
+		Proof: p.GetProof(),
+		Public: zk.ShotPublic{
+			Root: root,
+			Hit:  uint8(pub.GetHit()),
+			Row:  uint8(pub.GetRow()),
+			Col:  uint8(pub.GetCol()),
+			Hash: string(h),
+		},
+		Encoding: codec.EncodingCompressed,
+	}, nil
+}
+
+func proofToPB(p codec.ShotProofPayload) *pb.ShotProof {
+	return &pb.ShotProof{
+		Proof: p.Proof,
+		Public: &pb.ShotPublic{
+			Root: fmt.Sprintf("0x%064x", p.Public.Root),
+			Hit:  uint32(p.Public.Hit),
+			Row:  uint32(p.Public.Row),
+			Col:  uint32(p.Public.Col),
+			Hash: string(merkle.Hash(p.Public.Hash).Normalize()),
+		},
+	}
+}
+
+func (g *grpcService) Init(_ context.Context, req *pb.InitRequest) (*pb.Board, error) {
+	var seed *uint64
+	if req.Seed != nil {
+		v := req.GetSeed()
+		seed = &v
+	}
+	b, err := g.s.initBoard(req.GetStrategy(), seed)
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	return boardToPB(b), nil
+}
+
+func (g *grpcService) Commit(ctx context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	b, err := boardFromPB(req.GetBoard())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	rootHex, err := g.s.commit(ctx, b, req.GetHash())
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	return &pb.CommitResponse{RootHex: rootHex}, nil
+}
+
+func (g *grpcService) Shoot(ctx context.Context, req *pb.ShootRequest) (*pb.ShootResponse, error) {
+	if g.s.RequirePeerCert && !grpcPeerCertOK(ctx) {
+		return nil, grpcErr(errPeerCert)
+	}
+	if req.GetRow() > 9 || req.GetCol() > 9 {
+		return nil, status.Error(codes.InvalidArgument, "row/col out of range")
+	}
+	res, err := g.s.shoot(ctx, int(req.GetRow()), int(req.GetCol()))
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	return &pb.ShootResponse{
+		Payload: proofToPB(res.Payload),
+		Bit:     uint32(res.Bit),
+		RootHex: res.RootHex,
+		Vk:      res.VK,
+	}, nil
+}
+
+func (g *grpcService) Fire(ctx context.Context, req *pb.ShootRequest) (*pb.VerifyResponse, error) {
+	res, err := g.s.fire(ctx, int(req.GetRow()), int(req.GetCol()))
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	return &pb.VerifyResponse{Valid: res.Valid, Hit: uint32(res.Hit)}, nil
+}
+
+func (g *grpcService) Verify(ctx context.Context, req *pb.VerifyRequest) (*pb.VerifyResponse, error) {
+	payload, err := proofFromPB(req.GetPayload())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "bad payload: "+err.Error())
+	}
+	res, err := g.s.verify(ctx, req.GetRootHex(), *payload, req.GetVk())
+	if err != nil {
+		return nil, grpcErr(err)
+	}
+	return &pb.VerifyResponse{Valid: res.Valid, Hit: uint32(res.Hit)}, nil
+}
+
+func (g *grpcService) Status(_ context.Context, _ *pb.StatusRequest) (*pb.StatusResponse, error) {
+	return g.s.statusPB(), nil
+}
+
+func (g *grpcService) SetPeer(ctx context.Context, req *pb.SetPeerRequest) (*pb.StatusResponse, error) {
+	var vkB64 string
+	if len(req.GetVk()) > 0 {
+		vkB64 = base64.StdEncoding.EncodeToString(req.GetVk())
+	}
+	self := req.GetSelfUrl()
+	if g.s.PublicURL != "" {
+		self = g.s.PublicURL
+	}
+	if err := g.s.setPeer(ctx, req.GetBaseUrl(), req.GetRootHex(), vkB64, self); err != nil {
+		return nil, grpcErr(err)
+	}
+	return g.s.statusPB(), nil
+}
+
+func grpcPeerCertOK(ctx context.Context) bool {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	return ok && peerCertOK(&info.State)
+}
+
+// same content as statusPayload
+func (s *Server) statusPB() *pb.StatusResponse {
+	t, g, ev, peer := s.snapshot()
+
+	out := &pb.StatusResponse{
+		StartedAt:  s.startAt,
+		MyId:       t.MyID,
+		OppId:      t.OppID,
+		MyRootHex:  t.MyRootHex,
+		OppRootHex: t.OppRootHex,
+		Turn:       &pb.TurnState{MyTurn: t.MyTurn, Ready: t.Ready, Decided: t.Decided},
+		Game: &pb.GameState{
+			HitsTaken: int32(g.HitsTaken),
+			HitsDealt: int32(g.HitsDealt),
+			Over:      g.Over,
+			Winner:    g.Winner,
+		},
+		Vk:          s.loadVK(),
+		DefenseLast: &pb.ShotEvent{},
+	}
+	if peer != nil {
+		vk, _ := base64.StdEncoding.DecodeString(peer.VKB64)
+		out.Peer = &pb.Peer{BaseUrl: peer.BaseURL, RootHex: peer.RootHex, Vk: vk}
+	}
+	if ev != nil {
+		out.DefenseLast = &pb.ShotEvent{
+			Row: int32(ev.Row), Col: int32(ev.Col), Bit: uint32(ev.Bit), N: int32(ev.N), At: ev.At,
+		}
+	}
+	return out
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	"battleship-zk/internal/codec"
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/zk"
+	"battleship-zk/pkg/api"
+	pb "battleship-zk/pkg/battleshipv1"
+)
+
+// grpcClient serves s over an in-memory listener
+func grpcClient(t *testing.T, s *Server) pb.BattleshipClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	s.RegisterGRPC(gs)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBattleshipClient(conn)
+}
+
+func TestGRPCErr(t *testing.T) {
+	want := map[api.ErrorCode]codes.Code{
+		api.CodeBadRequest:       codes.InvalidArgument,
+		api.CodeMethodNotAllowed: codes.Unimplemented,
+		api.CodeInvalidBoard:     codes.InvalidArgument,
+		api.CodeNotCommitted:     codes.FailedPrecondition,
+		api.CodeNotYourTurn:      codes.FailedPrecondition,
+		api.CodeCellTargeted:     codes.AlreadyExists,
+		api.CodeGameOver:         codes.FailedPrecondition,
+		api.CodeBadProof:         codes.InvalidArgument,
+		api.CodeUnauthenticated:  codes.Unauthenticated,
+		api.CodePeerUnavailable:  codes.Unavailable,
+		api.CodeForbiddenOrigin:  codes.PermissionDenied,
+		api.CodeTooLarge:         codes.InvalidArgument,
+		api.CodeRateLimited:      codes.ResourceExhausted,
+		api.CodeProverBusy:       codes.ResourceExhausted,
+		api.CodeUnavailable:      codes.Unavailable,
+		api.CodeInternal:         codes.Internal,
+	}
+	for _, code := range api.ErrorCodes {
+		w, ok := want[code]
+		if !ok {
+			t.Errorf("no gRPC code expected for %s", code)
+			continue
+		}
+		st := status.Convert(grpcErr(opErr(code, "msg of "+string(code))))
+		if st.Code() != w || st.Message() != "msg of "+string(code) {
+			t.Errorf("%s: %v %q, want %v", code, st.Code(), st.Message(), w)
+		}
+	}
+	if got := status.Code(grpcErr(errors.New("plain"))); got != codes.Internal {
+		t.Errorf("plain error: %v, want %v", got, codes.Internal)
+	}
+}
+
+// testProof has valid curve points, it decodes like a real proof but does
+// not verify
+func testProof(t *testing.T) []byte {
+	t.Helper()
+	_, _, g1, g2 := bn254.Generators()
+	bi := func(e fp.Element) *big.Int { return e.BigInt(new(big.Int)) }
+	proof, err := zk.ProofFromPoints(&zk.ProofPoints{
+		A: [2]*big.Int{bi(g1.X), bi(g1.Y)},
+		B: [2][2]*big.Int{{bi(g2.X.A0), bi(g2.X.A1)}, {bi(g2.Y.A0), bi(g2.Y.A1)}},
+		C: [2]*big.Int{bi(g1.X), bi(g1.Y)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func TestProofPB(t *testing.T) {
+	p := codec.ShotProofPayload{
+		Proof:    testProof(t),
+		Public:   zk.ShotPublic{Root: big.NewInt(99), Hit: 1, Row: 9, Col: 4, Hash: string(merkle.HashPoseidon2)},
+		Encoding: codec.EncodingCompressed,
+	}
+	got, err := proofFromPB(proofToPB(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*got, p) {
+		t.Errorf("round trip %+v, want %+v", *got, p)
+	}
+	// the zero hash goes out as mimc
+	p.Public.Hash = ""
+	if h := proofToPB(p).GetPublic().GetHash(); h != string(merkle.HashMiMC) {
+		t.Errorf("hash %q, want %q", h, merkle.HashMiMC)
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(*pb.ShotProof)
+		wantErr string
+	}{
+		{"no public", func(x *pb.ShotProof) { x.Public = nil }, "missing public"},
+		{"hit out of range", func(x *pb.ShotProof) { x.Public.Hit = 2 }, "out of range"},
+		{"row out of range", func(x *pb.ShotProof) { x.Public.Row = 10 }, "out of range"},
+		{"hash", func(x *pb.ShotProof) { x.Public.Hash = "sha256" }, "sha256"},
+		{"root not reduced", func(x *pb.ShotProof) { x.Public.Root = "0x" + strings.Repeat("f", 64) }, "not reduced"},
+		{"root not hex", func(x *pb.ShotProof) { x.Public.Root = "99" }, "0x-prefixed"},
+		{"proof bytes", func(x *pb.ShotProof) { x.Proof = x.Proof[:10] }, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			x := proofToPB(p)
+			tc.edit(x)
+			_, err := proofFromPB(x)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// commit on both servers, shoot the defender and verify the answer on the
+// attacker, all over gRPC
+func TestGRPCRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys := t.TempDir()
+	attacker, defender := newTestServer(t), newTestServer(t)
+	attacker.KeysDir, defender.KeysDir = keys, keys
+	ac, dc := grpcClient(t, attacker), grpcClient(t, defender)
+
+	var board *pb.Board // the defender's
+	for i, c := range []pb.BattleshipClient{ac, dc} {
+		seed := uint64(8 + i)
+		b, err := c.Init(ctx, &pb.InitRequest{Seed: &seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Commit(ctx, &pb.CommitRequest{Board: b}); err != nil {
+			t.Fatal(err)
+		}
+		board = b
+	}
+
+	// shooting before it's the opponent's turn is a failed precondition
+	if _, err := dc.Shoot(ctx, &pb.ShootRequest{Row: 0, Col: 0}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("shoot out of turn: %v, want %v", err, codes.FailedPrecondition)
+	}
+	for s, mine := range map[*Server]string{attacker: "me", defender: "opponent"} {
+		s.mu.Lock()
+		s.turn.MyTurn, s.turn.Ready, s.turn.Decided = mine, true, true
+		s.mu.Unlock()
+	}
+
+	b, err := boardFromPB(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, col := 0, 0
+	for i, v := range b.Flatten() {
+		if v == 1 {
+			row, col = i/10, i%10
+			break
+		}
+	}
+	shot, err := dc.Shoot(ctx, &pb.ShootRequest{Row: uint32(row), Col: uint32(col)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shot.GetBit() != 1 || shot.GetPayload().GetPublic().GetRow() != uint32(row) || len(shot.GetVk()) == 0 {
+		t.Fatalf("shoot answered %+v", shot)
+	}
+	if _, err := dc.Shoot(ctx, &pb.ShootRequest{Row: uint32(row), Col: uint32(col)}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("second shot in the turn: %v, want %v", err, codes.FailedPrecondition)
+	}
+
+	// a flipped hit bit is a bad proof and keeps the turn
+	flipped := proto.Clone(shot.GetPayload()).(*pb.ShotProof)
+	flipped.Public.Hit = 0
+	if _, err := ac.Verify(ctx, &pb.VerifyRequest{RootHex: shot.GetRootHex(), Payload: flipped, Vk: shot.GetVk()}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("verify of a flipped hit: %v, want %v", err, codes.InvalidArgument)
+	}
+	res, err := ac.Verify(ctx, &pb.VerifyRequest{RootHex: shot.GetRootHex(), Payload: shot.GetPayload(), Vk: shot.GetVk()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.GetValid() || res.GetHit() != 1 {
+		t.Fatalf("verify %+v", res)
+	}
+	st, err := ac.Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetGame().GetHitsDealt() != 1 || st.GetTurn().GetMyTurn() != "opponent" {
+		t.Errorf("attacker status after verify: game %+v, turn %+v", st.GetGame(), st.GetTurn())
+	}
+}
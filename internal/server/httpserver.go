@@ -1,69 +1,157 @@
 package server
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"math/big"
+	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
-	"battleship-zk/internal/app"
 	"battleship-zk/internal/codec"
 	"battleship-zk/internal/game"
+	"battleship-zk/internal/logging"
 	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/zk"
+	"battleship-zk/pkg/api"
 	"battleship-zk/web"
 )
 
-const totalShipCells = 17
-
 type Server struct {
 	KeysDir    string
 	SecretPath string        
 	VKPath     string 
+	// when set the committed secret is encrypted on disk with it
+	Passphrase []byte
+	// without a Passphrase the committed secret stays in memory, unless
+	// this is set: then it's saved to SecretPath in plain text
+	PersistPlaintext bool
+	// game progress is saved here by Close and restored by LoadState
+	StatePath string
+	// used for every call to the peer's server, set it up with our client
+	// cert for mTLS (TLSOptions.PeerClient). nil is http.DefaultClient.
+	PeerHTTP *http.Client
+	// shoot only accepts connections with a verified client cert, i.e. the
+	// peer's server (needs a TLS listener with ClientCAs)
+	RequirePeerCert bool
+	// our base url as the peer reaches us, otherwise taken from the request
+	// that registered the peer
+	PublicURL string
+	// which other web pages may call the API, see WithCORS
+	CORS CORSPolicy
+	// request and proving limits, DefaultLimits if nil. Read once, at the
+	// first request.
+	Limits *Limits
+	// used by /v1/commit and /v1/init when the request names none, the
+	// zero values are mimc and uniform
+	Hash     merkle.Hash
+	Strategy game.Strategy
 
 	mu        sync.RWMutex
 	sec       *codec.Secret
-	peer      *PeerInfo
+	peer      *api.Peer
 	turn      *turnState
 	game      *gameState
-	lastEvt   *ShotEvent
+	lastEvt   *api.ShotEvent
 	shotsTried map[string]bool
+	shooting  bool // a shot of the opponent's turn is being answered
+	events    hub
+	mon       *peerMonitor
+	metrics   *serverMetrics
+
+	limitsOnce sync.Once
+	limiter    *rateLimiter
+	pool       *provePool
+	maxBody    int64
 
 	// we use this just to be able to determine which server started first for turns
 	startAt int64
 }
 
-type PeerInfo struct {
-	BaseURL string `json:"baseUrl"`
-	RootHex string `json:"rootHex,omitempty"`
-	VKB64   string `json:"vkB64,omitempty"`
-}
-
 func New(keysDir, secretPath string) *Server {
 	s := &Server{
 		KeysDir:     keysDir,
 		SecretPath:  secretPath,
-		VKPath:      filepath.Join(keysDir, "shot.vk"),
+		VKPath:      zk.VKPath(keysDir, merkle.HashMiMC),
 		shotsTried:  make(map[string]bool),
 		startAt:     time.Now().UnixMilli(),
 		turn:        &turnState{MyTurn: "", Ready: false, Decided: false},
 		game:        &gameState{},
 	}
+	// a peer coming up or restarting can decide the turn order
+	s.metrics = newServerMetrics(s)
+	s.mon = newPeerMonitor(func(ctx context.Context, url string) (int64, error) {
+		return s.metrics.timedProbe(func() (int64, error) { return probePeer(ctx, s.peerHTTP(), url) })
+	}, func() {
+		ctx := context.Background()
+		if h := s.mon.health(); h != nil {
+			l := s.logger(ctx).With("online", h.Online, "failures", h.Failures)
+			if n := len(h.History); n > 0 && h.History[n-1].Error != "" {
+				l = l.With("err", h.History[n-1].Error)
+			}
+			l.Info("peer status changed")
+		}
+		s.updateTurn(ctx, func(*turnState) {})
+	})
+	go s.mon.run()
 	return s
 }
 
+// Close stops the peer monitor, ends /v1/ws connections and saves the game
+// state. Call it after the listeners stopped taking requests.
+func (s *Server) Close() error {
+	s.mon.close()
+	s.events.closeAll()
+	return s.SaveState()
+}
+
+// LoadSecret restores a previously committed secret from SecretPath, if any
+func (s *Server) LoadSecret() error {
+	if _, err := os.Stat(s.SecretPath); os.IsNotExist(err) {
+		return nil
+	}
+	sec, err := codec.LoadSecret(s.SecretPath, s.Passphrase)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", s.SecretPath, err)
+	}
+	rootHex, err := computeRootHex(sec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.sec = sec
+	if s.turn == nil {
+		s.turn = &turnState{}
+	}
+	s.turn.MyRootHex = rootHex
+	s.mu.Unlock()
+	return nil
+}
+
+// handlers are the API routes, each one is documented in api.OpenAPI
+func (s *Server) handlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		"/v1/init":         http.HandlerFunc(s.handleInit),
+		"/v1/commit":       http.HandlerFunc(s.handleCommit),
+		"/v1/shoot":        http.HandlerFunc(s.handleShoot),
+		"/v1/verify":       http.HandlerFunc(s.handleVerify),
+		"/v1/fire":         http.HandlerFunc(s.handleFire),
+		"/v1/status":       http.HandlerFunc(s.handleStatus),
+		"/v1/peer":         http.HandlerFunc(s.handlePeerPut),
+		"/v1/openapi.json": http.HandlerFunc(s.handleOpenAPI),
+		"/v1/ws":           http.HandlerFunc(s.handleWS),
+		"/metrics":         s.handleMetrics(),
+	}
+}
+
 func (s *Server) Routes(mux *http.ServeMux) {
-	mux.HandleFunc("/v1/init", s.handleInit)
-	mux.HandleFunc("/v1/commit", s.handleCommit)
-	mux.HandleFunc("/v1/shoot", s.handleShoot)
-	mux.HandleFunc("/v1/verify", s.handleVerify)
-	mux.HandleFunc("/v1/status", s.handleStatus)
-	mux.HandleFunc("/v1/peer", s.handlePeerPut)
+	for pattern, h := range s.handlers() {
+		mux.Handle(pattern, h)
+	}
 
 	gui := http.FileServer(web.FS())
 	mux.Handle("/", gui)
@@ -85,15 +173,10 @@ func (s *Server) currentSecret() (*codec.Secret, error) {
 }
 
 func computeRootHex(sec *codec.Secret) (string, error) {
-	if sec.SaltHex == "" || len(sec.SaltHex) < 3 || sec.SaltHex[:2] != "0x" {
-		return "", fmt.Errorf("missing or invalid salt in secret")
-	}
-	salt, ok := new(big.Int).SetString(sec.SaltHex[2:], 16)
-	if !ok {
-		return "", fmt.Errorf("cannot parse salt")
+	salted, err := sec.SaltedRoot()
+	if err != nil {
+		return "", err
 	}
-	treeRoot := sec.Tree.Root()
-	salted := merkle.HashNodeMiMC(salt, treeRoot)
 	return fmt.Sprintf("0x%x", salted), nil
 }
 
@@ -104,54 +187,48 @@ func (s *Server) handleInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		writeErr(w, errMethod)
 		return
 	}
-	b, err := app.InitBoard()
+	// body is optional, the web UI posts {}
+	var req api.InitRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeErr(w, badJSON(err))
+			return
+		}
+	}
+	if q := r.URL.Query().Get("strategy"); q != "" {
+		req.Strategy = q
+	}
+	b, err := s.initBoard(req.Strategy, req.Seed)
 	if err != nil {
-		writeJSON(w, 500, map[string]string{"error": err.Error()})
+		writeErr(w, err)
 		return
 	}
 	writeJSON(w, 200, b)
 }
 
-type commitReq struct {
-	Board game.Board `json:"board"`
-}
-
 func (s *Server) handleCommit(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		writeErr(w, errMethod)
 		return
 	}
-	var req commitReq
+	var req api.CommitRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, 400, map[string]string{"error": "bad json"})
+		writeErr(w, badJSON(err))
 		return
 	}
-	res, err := app.Commit(req.Board, s.KeysDir)
+	rootHex, err := s.commit(r.Context(), game.Board(req.Board), req.Hash)
 	if err != nil {
-		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		writeErr(w, err)
 		return
 	}
-
-	s.mu.Lock()
-	s.sec = &res.Secret
-	s.mu.Unlock()
-
-	rootHex, _ := computeRootHex(&res.Secret)
-	_, _ = s.updateTurn(func(t *turnState) { t.MyRootHex = rootHex })
-
-	writeJSON(w, 200, map[string]any{"rootHex": rootHex})
-}
-
-type shootReq struct {
-	Row int `json:"row"`
-	Col int `json:"col"`
+	writeJSON(w, 200, api.CommitResponse{RootHex: rootHex})
 }
 
 func (s *Server) handleShoot(w http.ResponseWriter, r *http.Request) {
@@ -160,140 +237,60 @@ func (s *Server) handleShoot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		writeErr(w, errMethod)
+		return
+	}
+	if s.RequirePeerCert && !peerCertOK(r.TLS) {
+		writeErr(w, errPeerCert)
 		return
 	}
-	var req shootReq
+	var req api.ShootRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, 400, map[string]string{"error": "bad json"})
+		writeErr(w, badJSON(err))
 		return
 	}
 
-	// we only let shoot if it not my turn
-	t, err := s.loadTurn()
+	res, err := s.shoot(r.Context(), req.Row, req.Col)
 	if err != nil {
-		writeJSON(w, 500, map[string]string{"error": "failed to read turn state"})
+		writeErr(w, err)
 		return
 	}
-	if !t.Ready || t.MyTurn != "opponent" {
-		writeJSON(w, 409, map[string]any{
-			"error":   "not allowed: it's not opponent's turn to shoot",
-			"myTurn":  t.MyTurn,
-			"ready":   t.Ready,
-			"decided": t.Decided,
-		})
+	payload, err := json.Marshal(res.Payload)
+	if err != nil {
+		writeErr(w, err)
 		return
 	}
-	if g, gErr := s.loadGame(); gErr == nil && g.Over {
-		writeJSON(w, 409, map[string]any{
-			"error":     "game is over",
-			"winner":    g.Winner,
-			"hitsTaken": g.HitsTaken,
-			"hitsDealt": g.HitsDealt,
-		})
-		return
+	resp := api.ShootResponse{
+		Payload: payload,
+		Bit:     res.Bit,
+		RootHex: res.RootHex,
 	}
-
-	// this to prevent duplicate shots on same cell
-	k := shotKey(req.Row, req.Col)
-	s.mu.Lock()
-	if s.shotsTried == nil {
-		s.shotsTried = make(map[string]bool)
-	}
-	if s.shotsTried[k] {
-		s.mu.Unlock()
-		writeJSON(w, 409, map[string]any{
-			"error":   "cell already targeted",
-			"row":     req.Row,
-			"col":     req.Col,
-			"myTurn":  t.MyTurn,
-			"ready":   t.Ready,
-			"decided": t.Decided,
-		})
-		return
+	if len(res.VK) > 0 {
+		resp.VKB64 = base64.StdEncoding.EncodeToString(res.VK)
 	}
-	s.shotsTried[k] = true
-	s.mu.Unlock()
+	writeJSON(w, 200, resp)
+}
 
-	sec, err := s.currentSecret()
-	if err != nil {
-		s.mu.Lock()
-		delete(s.shotsTried, k)
-		s.mu.Unlock()
-		writeJSON(w, 400, map[string]string{"error": err.Error()})
+func (s *Server) handleFire(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-
-	res, err := app.Shoot(*sec, s.KeysDir, req.Row, req.Col)
-	if err != nil {
-		s.mu.Lock()
-		delete(s.shotsTried, k)
-		s.mu.Unlock()
-		writeJSON(w, 400, map[string]string{"error": err.Error()})
+	if r.Method != http.MethodPost {
+		writeErr(w, errMethod)
 		return
 	}
-
-	// this is just for coloring in the UI
-	s.recordShot(req.Row, req.Col, res.Bit)
-
-	if res.Bit == 1 {
-		_, _ = s.updateGame(func(g *gameState) {
-			if !g.Over {
-				g.HitsTaken++
-				if g.HitsTaken >= totalShipCells {
-					g.Over = true
-					g.Winner = "opponent"
-				}
-			}
-		})
-	}
-
-	var vkB64 string
-	if data, err := os.ReadFile(s.VKPath); err == nil && len(data) > 0 {
-		vkB64 = base64.StdEncoding.EncodeToString(data)
+	var req api.ShootRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, badJSON(err))
+		return
 	}
-
-	rootHex, err := computeRootHex(sec)
+	res, err := s.fire(r.Context(), req.Row, req.Col)
 	if err != nil {
-		writeJSON(w, 500, map[string]string{"error": err.Error()})
+		writeErr(w, err)
 		return
 	}
-
-	_, _ = s.updateTurn(func(t *turnState) { t.MyTurn = "me" })
-
-	resp := map[string]any{
-		"payload": res.Payload,
-		"bit":     res.Bit,
-		"rootHex": rootHex,
-		"vkB64":   vkB64,
-	}
-	writeJSON(w, 200, resp)
-}
-
-type flexString string
-
-func (f *flexString) UnmarshalJSON(b []byte) error {
-	var s string
-	if len(b) > 0 && b[0] == '"' {
-		if err := json.Unmarshal(b, &s); err != nil {
-			return err
-		}
-		*f = flexString(s)
-		return nil
-	}
-	var n json.Number
-	if err := json.Unmarshal(b, &n); err != nil {
-		return err
-	}
-	*f = flexString(n.String())
-	return nil
-}
-
-type verifyReq struct {
-	RootHex string          `json:"rootHex,omitempty"`
-	RootDec flexString      `json:"rootDec,omitempty"`
-	Payload json.RawMessage `json:"payload"`
-	VKB64   string          `json:"vkB64,omitempty"`
+	writeJSON(w, 200, api.VerifyResponse{Valid: res.Valid, Hit: res.Hit})
 }
 
 func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
@@ -302,261 +299,163 @@ func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		writeErr(w, errMethod)
 		return
 	}
 
-	var req verifyReq
+	var req api.VerifyRequest
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(&req); err != nil {
-		writeJSON(w, 400, map[string]string{"error": "bad json: " + err.Error()})
-		return
-	}
-
-	t, err := s.loadTurn()
-	if err != nil {
-		writeJSON(w, 500, map[string]string{"error": "failed to read turn state"})
-		return
-	}
-	if !t.Ready || t.MyTurn != "me" {
-		writeJSON(w, 409, map[string]any{
-			"error":   "not allowed: it's not our turn to verify an attack",
-			"myTurn":  t.MyTurn,
-			"ready":   t.Ready,
-			"decided": t.Decided,
-		})
-		return
-	}
-	if g, gErr := s.loadGame(); gErr == nil && g.Over {
-		writeJSON(w, 409, map[string]any{
-			"error":     "game is over",
-			"winner":    g.Winner,
-			"hitsTaken": g.HitsTaken,
-			"hitsDealt": g.HitsDealt,
-		})
+		writeErr(w, badJSON(err))
 		return
 	}
 
 	if strings.TrimSpace(req.VKB64) == "" {
-		writeJSON(w, 400, map[string]string{"error": "vkB64 required"})
+		writeErr(w, opErr(api.CodeBadRequest, "vkB64 required"))
 		return
 	}
 	rawVK, err := base64.StdEncoding.DecodeString(req.VKB64)
 	if err != nil || len(rawVK) == 0 {
-		writeJSON(w, 400, map[string]string{"error": "invalid vkB64"})
+		writeErr(w, opErr(api.CodeBadRequest, "invalid vkB64"))
 		return
 	}
-	// i have this because the verify function i have now expects a path and not raw bytes (will change later)
-	f, err := os.CreateTemp("", "vk-*.vk")
+	payload, err := codec.DecodeShotProof(req.Payload)
 	if err != nil {
-		writeJSON(w, 500, map[string]string{"error": err.Error()})
+		writeErr(w, s.badProof("bad payload: "+err.Error()))
 		return
 	}
-	if _, err := f.Write(rawVK); err != nil {
-		_ = f.Close()
-		_ = os.Remove(f.Name())
-		writeJSON(w, 500, map[string]string{"error": err.Error()})
-		return
-	}
-	_ = f.Close()
-	vkPath := f.Name()
-	defer os.Remove(vkPath)
 
-	var rootInt *big.Int
-	if strings.TrimSpace(req.RootHex) != "" {
-		h := req.RootHex
-		if !strings.HasPrefix(h, "0x") && !strings.HasPrefix(h, "0X") {
-			h = "0x" + h
-		}
-		n := new(big.Int)
-		if _, ok := n.SetString(h[2:], 16); !ok {
-			writeJSON(w, 400, map[string]string{"error": "invalid rootHex"})
-			return
-		}
-		rootInt = n
-	} else if strings.TrimSpace(string(req.RootDec)) != "" {
-		n := new(big.Int)
-		if _, ok := n.SetString(string(req.RootDec), 10); !ok {
-			writeJSON(w, 400, map[string]string{"error": "invalid rootDec"})
-			return
-		}
-		rootInt = n
-	} else {
-		writeJSON(w, 400, map[string]string{"error": "must provide rootHex or rootDec"})
-		return
-	}
-
-	var payloadMap map[string]any
-	if err := json.Unmarshal(req.Payload, &payloadMap); err != nil {
-		writeJSON(w, 400, map[string]string{"error": "bad json in payload"})
-		return
-	}
-	if pub, ok := payloadMap["public"].(map[string]any); ok {
-		delete(pub, "root")
-	}
-	payloadSanitized, _ := json.Marshal(payloadMap)
-
-	var payload codec.ShotProofPayload
-	if err := json.Unmarshal(payloadSanitized, &payload); err != nil {
-		writeJSON(w, 400, map[string]string{"error": "bad json in payload: " + err.Error()})
-		return
-	}
-
-	res, err := app.VerifyWithRoot(vkPath, rootInt, payload)
+	res, err := s.verify(r.Context(), req.RootHex, *payload, rawVK)
 	if err != nil {
-		writeJSON(w, 400, map[string]string{"error": err.Error()})
+		writeErr(w, err)
 		return
 	}
+	writeJSON(w, 200, api.VerifyResponse{Valid: res.Valid, Hit: res.Hit})
+}
 
-	if res.Valid {
-		_, _ = s.updateTurn(func(t *turnState) { t.MyTurn = "opponent" })
+// vk of the committed hash, VKPath until something is committed
+func (s *Server) vkPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.sec != nil {
+		return zk.VKPath(s.KeysDir, s.sec.Hash)
 	}
+	return s.VKPath
+}
 
-	// Attack-side game state update on hit
-	if res.Hit == 1 {
-		_, _ = s.updateGame(func(g *gameState) {
-			if !g.Over {
-				g.HitsDealt++
-				if g.HitsDealt >= totalShipCells {
-					g.Over = true
-					g.Winner = "me"
-				}
-			}
-		})
+func (s *Server) loadVK() []byte {
+	data, err := os.ReadFile(s.vkPath())
+	if err != nil || len(data) == 0 {
+		return nil
 	}
-
-	writeJSON(w, 200, res)
+	return data
 }
 
 func (s *Server) loadVKB64() string {
-	data, err := os.ReadFile(s.VKPath)
-	if err != nil || len(data) == 0 {
+	data := s.loadVK()
+	if data == nil {
 		return ""
 	}
 	return base64.StdEncoding.EncodeToString(data)
 }
 
-func (s *Server) statusPayload() map[string]any {
+// copies of the state a status report is made of
+func (s *Server) snapshot() (t turnState, g gameState, ev *api.ShotEvent, peer *api.Peer) {
 	s.mu.RLock()
-	t := *s.turn
-	g := *s.game
-	ev := s.lastEvt
-	peer := s.peer
-	s.mu.RUnlock()
-
-	defense := any(map[string]any{"n": 0})
-	if ev != nil {
-		defense = map[string]any{
-			"row": ev.Row, "col": ev.Col, "bit": ev.Bit, "n": ev.N, "at": ev.At,
-		}
-	}
-
-	return map[string]any{
-		"startedAt": s.startAt,
-		"myId":      t.MyID,
-		"oppId":     t.OppID,
-
-		"myRootHex":  t.MyRootHex,
-		"oppRootHex": t.OppRootHex,
-
-		"peer": peer,
-
-		"turn": map[string]any{
-			"myTurn":  t.MyTurn,
-			"ready":   t.Ready,
-			"decided": t.Decided,
-		},
-		"game": map[string]any{
-			"hitsTaken": g.HitsTaken,
-			"hitsDealt": g.HitsDealt,
-			"over":      g.Over,
-			"winner":    g.Winner,
+	defer s.mu.RUnlock()
+	return *s.turn, *s.game, s.lastEvt, s.peer
+}
+
+func (s *Server) statusPayload() api.Status {
+	t, g, ev, peer := s.snapshot()
+
+	st := api.Status{
+		StartedAt:  s.startAt,
+		MyID:       t.MyID,
+		OppID:      t.OppID,
+		MyRootHex:  t.MyRootHex,
+		OppRootHex: t.OppRootHex,
+		Peer:       peer,
+		Turn:       api.TurnState{MyTurn: t.MyTurn, Ready: t.Ready, Decided: t.Decided},
+		Game: api.GameState{
+			HitsTaken: g.HitsTaken,
+			HitsDealt: g.HitsDealt,
+			Over:      g.Over,
+			Winner:    g.Winner,
 		},
-		"vkB64":       s.loadVKB64(),
-		"defenseLast": defense,
+		VKB64: s.loadVKB64(),
 	}
+	if s.mon != nil {
+		st.PeerHealth = s.mon.health()
+	}
+	st.Load = s.load()
+	if ev != nil {
+		st.DefenseLast = *ev
+	}
+	return st
 }
 
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		writeErr(w, errMethod)
 		return
 	}
 	writeJSON(w, 200, s.statusPayload())
 }
 
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, errMethod)
+		return
+	}
+	writeJSON(w, 200, api.OpenAPI())
+}
 
-func selfBaseURL(r *http.Request) string {
+// selfBaseURL is PublicURL, or else the url the request reached us on. The
+// scheme comes from the connection, behind a proxy set PublicURL instead.
+func (s *Server) selfBaseURL(r *http.Request) string {
+	if s.PublicURL != "" {
+		return strings.TrimRight(s.PublicURL, "/")
+	}
 	scheme := "http"
-	if xfp := r.Header.Get("X-Forwarded-Proto"); xfp != "" {
-		scheme = xfp
-	} else if r.TLS != nil {
+	if r.TLS != nil {
 		scheme = "https"
 	}
 	host := strings.TrimSpace(r.Host)
 	return scheme + "://" + strings.TrimRight(host, "/")
 }
 
-type peerPutReq struct {
-	BaseURL string `json:"baseUrl"`
-	RootHex string `json:"rootHex,omitempty"`
-	VKB64   string `json:"vkB64,omitempty"`
-}
-
 func (s *Server) handlePeerPut(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 	if r.Method != http.MethodPut {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		writeErr(w, errMethod)
 		return
 	}
 
-	var req peerPutReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.BaseURL) == "" {
-		writeJSON(w, 400, map[string]string{"error": "bad json or missing baseUrl"})
+	var req api.Peer
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, badJSON(err))
 		return
 	}
-
-	s.mu.Lock()
-	s.peer = &PeerInfo{
-		BaseURL: strings.TrimRight(req.BaseURL, "/"),
-		RootHex: req.RootHex,
-		VKB64:   req.VKB64,
+	if strings.TrimSpace(req.BaseURL) == "" {
+		writeErr(w, opErr(api.CodeBadRequest, "missing baseUrl"))
+		return
 	}
-	s.mu.Unlock()
 
-	_, _ = s.updateTurn(func(t *turnState) {
-		if strings.TrimSpace(t.MyID) == "" {
-			t.MyID = selfBaseURL(r)
-		}
-		t.OppID = strings.TrimRight(req.BaseURL, "/")
-		if strings.TrimSpace(req.RootHex) != "" {
-			t.OppRootHex = req.RootHex
-		}
-	})
+	if err := s.setPeer(r.Context(), req.BaseURL, req.RootHex, req.VKB64, s.selfBaseURL(r)); err != nil {
+		writeErr(w, err)
+		return
+	}
 
 	// Return unified status
 	writeJSON(w, 200, s.statusPayload())
 }
 
 
-func WithCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// this doesn't matter much for demo
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
-}
-
 type turnState struct {
 	MyTurn     string `json:"myTurn"` 
 	MyRootHex  string `json:"myRootHex,omitempty"`
@@ -583,63 +482,54 @@ func normalizeID(sid string) string {
 	return strings.ToLower(sid)
 }
 
-func (s *Server) ping(baseURL string) bool {
-	client := &http.Client{Timeout: 1500 * time.Millisecond}
-	resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/v1/status")
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
-}
-
-func (s *Server) peerStatus(baseURL string) (online bool, startedAt int64) {
-	if strings.TrimSpace(baseURL) == "" {
-		return false, 0
-	}
-	url := strings.TrimRight(baseURL, "/") + "/v1/status"
-	client := &http.Client{Timeout: 1500 * time.Millisecond}
-	resp, err := client.Get(url)
-	if err != nil {
-		return false, 0
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return false, 0
-	}
-	var m map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
-		return false, 0
-	}
-	if v, ok := m["startedAt"].(float64); ok && int64(v) > 0 {
-		return true, int64(v)
-	}
-	return false, 0
-}
-
 // we decide turn by timestamp
-func (s *Server) updateTurn(mut func(*turnState)) (*turnState, error) {
+func (s *Server) updateTurn(ctx context.Context, mut func(*turnState)) *turnState {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if s.turn == nil {
 		s.turn = &turnState{}
 	}
+	before := *s.turn
+	t := s.decideTurn(mut)
+	changed := t.MyTurn != before.MyTurn || t.Ready != before.Ready || t.Decided != before.Decided
+	var attrs []any
+	if changed {
+		s.events.publish(turnEvent(&t))
+		attrs = gameAttrs(&t, s.game)
+	}
+	s.mu.Unlock()
+
+	if changed {
+		l := logging.From(ctx).With(attrs...)
+		switch {
+		case t.Decided && !before.Decided:
+			l.Info("turn order decided", "my_turn", t.MyTurn)
+		case t.Ready != before.Ready:
+			l.Info("turn ready changed", "ready", t.Ready, "my_turn", t.MyTurn)
+		default:
+			l.Debug("turn changed", "my_turn", t.MyTurn)
+		}
+	}
+	return &t
+}
+
+// decideTurn applies mut to s.turn and decides the order once both servers
+// are known and online, call it with s.mu held
+func (s *Server) decideTurn(mut func(*turnState)) turnState {
 	mut(s.turn)
 
 	myID := normalizeID(s.turn.MyID)
 	oppID := normalizeID(s.turn.OppID)
 	haveIDs := myID != "" && oppID != ""
 
+	// cached by the peer monitor, no network call under the lock
 	online, oppStarted := false, int64(0)
-	if haveIDs {
-		online, oppStarted = s.peerStatus(oppID)
+	if haveIDs && s.mon != nil {
+		online, oppStarted = s.mon.cached(oppID)
 	}
 
 	if s.turn.Decided {
 		s.turn.Ready = haveIDs && online
-		cp := *s.turn
-		return &cp, nil
+		return *s.turn
 	}
 
 	myStarted := s.startAt
@@ -662,29 +552,21 @@ func (s *Server) updateTurn(mut func(*turnState)) (*turnState, error) {
 		s.turn.Decided = false
 	}
 
-	cp := *s.turn
-	return &cp, nil
-}
-
-type ShotEvent struct {
-	Row int   `json:"row"`
-	Col int   `json:"col"`
-	Bit uint8 `json:"bit"`
-	N   int   `json:"n"` 	// counter
-	At  int64 `json:"at"` 	//time
+	return *s.turn
 }
 
-func (s *Server) recordShot(row, col int, bit uint8) {
+func (s *Server) recordShot(row, col int, bit uint8) api.ShotEvent {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	n := 1
 	if s.lastEvt != nil {
 		n = s.lastEvt.N + 1
 	}
-	s.lastEvt = &ShotEvent{
+	s.lastEvt = &api.ShotEvent{
 		Row: row, Col: col, Bit: bit, N: n,
 		At: time.Now().UnixMilli(),
 	}
+	return *s.lastEvt
 }
 
 type gameState struct {
@@ -692,6 +574,7 @@ type gameState struct {
 	HitsDealt int    `json:"hitsDealt"`
 	Over      bool   `json:"over"`
 	Winner    string `json:"winner"`
+	Shots     int    `json:"shots"` // both sides, the turn number in logs is Shots+1
 }
 
 func (s *Server) loadGame() (*gameState, error) {
@@ -704,15 +587,26 @@ func (s *Server) loadGame() (*gameState, error) {
 	return &cp, nil
 }
 
-func (s *Server) updateGame(mut func(*gameState)) (*gameState, error) {
+func (s *Server) updateGame(ctx context.Context, mut func(*gameState)) *gameState {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if s.game == nil {
 		s.game = &gameState{}
 	}
+	wasOver := s.game.Over
 	mut(s.game)
-	cp := *s.game
-	return &cp, nil
+	g := *s.game
+	over := g.Over && !wasOver
+	var attrs []any
+	if over {
+		s.events.publish(api.Event{Type: api.EventGameOver, Game: apiGame(&g)})
+		attrs = gameAttrs(s.turn, &g)
+	}
+	s.mu.Unlock()
+
+	if over {
+		logging.From(ctx).With(attrs...).Info("game over", "winner", g.Winner, "hits_taken", g.HitsTaken, "hits_dealt", g.HitsDealt)
+	}
+	return &g
 }
 
 func shotKey(r, c int) string { return fmt.Sprintf("%d,%d", r, c) }
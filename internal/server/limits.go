@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpcpeer "google.golang.org/grpc/peer"
+
+	"battleship-zk/internal/limits"
+	"battleship-zk/pkg/api"
+)
+
+// Limits protect the server from a client that sends too much, see
+// limits.Limits
+type Limits = limits.Limits
+
+var DefaultLimits = limits.Default
+
+const (
+	limiterIdle     = time.Minute // buckets unused this long are dropped
+	proveRetryAfter = 5 * time.Second
+)
+
+// rateLimiter is a token bucket per client
+type rateLimiter struct {
+	rate, burst float64
+
+	mu        sync.Mutex
+	clients   map[string]*bucket
+	lastSweep time.Time
+	limited   uint64
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(max(burst, 1)), clients: make(map[string]*bucket)}
+}
+
+// allow takes a token for key, or says how long until there is one
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil || l.rate <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > limiterIdle {
+		for k, b := range l.clients {
+			if now.Sub(b.last) > limiterIdle {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.clients[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	l.limited++
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *rateLimiter) count() uint64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limited
+}
+
+// provePool lets ProveWorkers shots prove at once and up to ProveQueue wait
+type provePool struct {
+	slots chan struct{}
+
+	mu       sync.Mutex
+	waiting  int
+	maxWait  int
+	rejected uint64
+}
+
+func newProvePool(workers, queue int) *provePool {
+	return &provePool{slots: make(chan struct{}, max(workers, 1)), maxWait: max(queue, 0)}
+}
+
+var errProverBusy = errors.New("prover busy")
+
+// acquire waits for a worker, release it when done. errProverBusy if the
+// queue is full, ctx.Err() if the caller gave up waiting.
+func (p *provePool) acquire(ctx context.Context) (release func(), err error) {
+	release = func() { <-p.slots }
+	select {
+	case p.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	p.mu.Lock()
+	if p.waiting >= p.maxWait {
+		p.rejected++
+		p.mu.Unlock()
+		return nil, errProverBusy
+	}
+	p.waiting++
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.waiting--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case p.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *provePool) stats() (workers, busy, queued, queueMax int, rejected uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return cap(p.slots), len(p.slots), p.waiting, p.maxWait, p.rejected
+}
+
+// initLimits builds the limiter and pool from s.Limits, once
+func (s *Server) initLimits() {
+	s.limitsOnce.Do(func() {
+		l := DefaultLimits
+		if s.Limits != nil {
+			l = *s.Limits
+		}
+		s.limiter = newRateLimiter(l.Rate, l.Burst)
+		s.pool = newProvePool(l.ProveWorkers, l.ProveQueue)
+		s.maxBody = l.MaxBodyBytes
+		if s.maxBody <= 0 {
+			s.maxBody = DefaultLimits.MaxBodyBytes
+		}
+	})
+}
+
+func (s *Server) load() *api.Load {
+	s.initLimits()
+	w, b, q, qm, rej := s.pool.stats()
+	return &api.Load{
+		ProveWorkers:  w,
+		ProveBusy:     b,
+		ProveQueued:   q,
+		ProveQueueMax: qm,
+		ProveRejected: rej,
+		RateLimited:   s.limiter.count(),
+	}
+}
+
+func clientIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func rateLimitErr(wait time.Duration) *opError {
+	e := opErr(api.CodeRateLimited, "too many requests")
+	e.retryAfter = wait
+	return e
+}
+
+// WithLimits rate limits /v1 requests per client ip and caps request bodies
+func (s *Server) WithLimits(next http.Handler) http.Handler {
+	s.initLimits()
+	maxBody := s.maxBody
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/") {
+			if ok, wait := s.limiter.allow(clientIP(r.RemoteAddr), time.Now()); !ok {
+				writeErr(w, rateLimitErr(wait))
+				return
+			}
+		}
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryLimiter is the gRPC side of WithLimits' rate limit, message size is
+// capped with grpc.MaxRecvMsgSize
+func (s *Server) UnaryLimiter() grpc.UnaryServerInterceptor {
+	s.initLimits()
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if p, ok := grpcpeer.FromContext(ctx); ok && p.Addr != nil {
+			if ok, wait := s.limiter.allow(clientIP(p.Addr.String()), time.Now()); !ok {
+				return nil, grpcErr(rateLimitErr(wait))
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// badJSON is the error for a body that didn't decode
+func badJSON(err error) *opError {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return opErr(api.CodeTooLarge, "request body over "+strconv.FormatInt(mbe.Limit, 10)+" bytes")
+	}
+	return opErr(api.CodeBadRequest, "bad json: "+err.Error())
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"battleship-zk/internal/game"
+	"battleship-zk/pkg/api"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	type step struct {
+		key  string
+		ms   int // since t0
+		ok   bool
+		wait time.Duration
+	}
+	tests := []struct {
+		name        string
+		rate        float64
+		burst       int
+		steps       []step
+		wantLimited uint64
+	}{
+		{"burst then refill", 2, 3, []step{
+			{"a", 0, true, 0},
+			{"a", 0, true, 0},
+			{"a", 0, true, 0},
+			{"a", 0, false, 500 * time.Millisecond},
+			{"a", 250, false, 250 * time.Millisecond},
+			{"a", 500, true, 0},
+			{"a", 500, false, 500 * time.Millisecond},
+		}, 3},
+		{"clients have their own bucket", 1, 1, []step{
+			{"a", 0, true, 0},
+			{"a", 0, false, time.Second},
+			{"b", 0, true, 0},
+			{"b", 100, false, 900 * time.Millisecond},
+		}, 2},
+		{"refill stops at burst", 10, 2, []step{
+			{"a", 0, true, 0},
+			{"a", 0, true, 0},
+			{"a", 60000, true, 0},
+			{"a", 60000, true, 0},
+			{"a", 60000, false, 100 * time.Millisecond},
+		}, 1},
+		{"burst below one is one", 1, 0, []step{
+			{"a", 0, true, 0},
+			{"a", 0, false, time.Second},
+		}, 1},
+		{"rate 0 is no limit", 0, 1, []step{
+			{"a", 0, true, 0},
+			{"a", 0, true, 0},
+			{"a", 0, true, 0},
+		}, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newRateLimiter(tc.rate, tc.burst)
+			for i, st := range tc.steps {
+				ok, wait := l.allow(st.key, at(st.ms))
+				if ok != st.ok || wait != st.wait {
+					t.Fatalf("step %d (%s at %dms): allow = %v, %v, want %v, %v", i, st.key, st.ms, ok, wait, st.ok, st.wait)
+				}
+			}
+			if got := l.count(); got != tc.wantLimited {
+				t.Errorf("count() = %d, want %d", got, tc.wantLimited)
+			}
+		})
+	}
+
+	var nilLimiter *rateLimiter
+	if ok, _ := nilLimiter.allow("a", t0); !ok {
+		t.Error("nil limiter refused a request")
+	}
+}
+
+// buckets of clients gone for limiterIdle are dropped and start full again
+func TestRateLimiterSweep(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	l := newRateLimiter(1, 1)
+	l.allow("a", t0)
+	l.allow("b", t0.Add(limiterIdle))
+	l.allow("b", t0.Add(limiterIdle+2*time.Second))
+	if _, ok := l.clients["a"]; ok || len(l.clients) != 1 {
+		t.Fatalf("clients after the sweep: %v", l.clients)
+	}
+}
+
+func TestProvePoolAcquire(t *testing.T) {
+	p := newProvePool(1, 1)
+	release, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the next shot waits in the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error, 1)
+	go func() {
+		_, err := p.acquire(ctx)
+		queued <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, _, q, _, _ := p.stats(); q == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second shot never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// a full queue is prover_busy right away
+	if _, err := p.acquire(context.Background()); !errors.Is(err, errProverBusy) {
+		t.Fatalf("acquire with a full queue: %v, want errProverBusy", err)
+	}
+
+	// the queued caller gives up
+	cancel()
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled acquire: %v, want %v", err, context.Canceled)
+	}
+	if w, busy, q, qm, rej := p.stats(); w != 1 || busy != 1 || q != 0 || qm != 1 || rej != 1 {
+		t.Fatalf("stats = %d %d %d %d %d", w, busy, q, qm, rej)
+	}
+
+	release()
+	release2, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+// a nil Limits is DefaultLimits, a Limits with Rate 0 turns rate limiting off
+func TestServerLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits *Limits
+		want   int // requests answered before the first 429, -1 for all
+	}{
+		{"default", nil, DefaultLimits.Burst},
+		{"rate 0", &Limits{}, -1},
+		{"burst 2", &Limits{Rate: 1, Burst: 2}, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.Limits = tc.limits
+			h := s.WithLimits(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			got := -1
+			for i := 0; i < 50; i++ {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/status", nil))
+				if w.Code == http.StatusTooManyRequests {
+					got = i
+					break
+				}
+			}
+			if got != tc.want {
+				t.Errorf("first 429 after %d requests, want %d", got, tc.want)
+			}
+		})
+	}
+
+	// bodies over the default cap when MaxBodyBytes is 0
+	s := newTestServer(t)
+	s.Limits = &Limits{}
+	h := s.WithLimits(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sink [1024]byte
+		for {
+			if _, err := r.Body.Read(sink[:]); err != nil {
+				writeErr(w, badJSON(err))
+				return
+			}
+		}
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/init", strings.NewReader(strings.Repeat("x", int(DefaultLimits.MaxBodyBytes)+1))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: %d, want 413", w.Code)
+	}
+}
+
+// with more prove workers than one, concurrent shots still get one answer
+// per turn: the first takes the turn, the others are not_your_turn
+func TestShootOnePerTurn(t *testing.T) {
+	s := newTestServer(t)
+	s.KeysDir = t.TempDir()
+	s.Limits = &Limits{ProveWorkers: 2, ProveQueue: 2}
+	b, err := game.GenerateBoard(game.NewSeededRand(3), game.StrategyUniform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := s.commit(context.Background(), b, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	midGame(s, root)
+	s.shotsTried = map[string]bool{}
+	mux := http.NewServeMux()
+	s.Routes(mux)
+
+	const shots = 4
+	codes := make([]api.ErrorCode, shots)
+	statuses := make([]int, shots)
+	var wg sync.WaitGroup
+	for i := range shots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/shoot", strings.NewReader(fmt.Sprintf(`{"row":%d,"col":%d}`, i, i))))
+			statuses[i] = rec.Code
+			var body api.Error
+			_ = json.Unmarshal(rec.Body.Bytes(), &body)
+			codes[i] = body.Code
+		}()
+	}
+	wg.Wait()
+
+	answered := 0
+	for i := range shots {
+		switch {
+		case statuses[i] == http.StatusOK:
+			answered++
+		case codes[i] != api.CodeNotYourTurn:
+			t.Errorf("shot %d: %d %s, want 200 or %s", i, statuses[i], codes[i], api.CodeNotYourTurn)
+		}
+	}
+	if answered != 1 {
+		t.Fatalf("%d shots answered in one turn, want 1", answered)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.game.Shots != midGameShots+1 || len(s.shotsTried) != 1 || s.shooting {
+		t.Errorf("after the turn: %d shots, cells tried %v, shooting %v", s.game.Shots, s.shotsTried, s.shooting)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"battleship-zk/internal/codec"
+	"battleship-zk/internal/logging"
+)
+
+// gameID names the game of the two committed roots. Both servers come up
+// with the same id, so their logs can be matched. Empty until both roots
+// are known.
+func gameID(t *turnState) string {
+	if t == nil || t.MyRootHex == "" || t.OppRootHex == "" {
+		return ""
+	}
+	norm := func(h string) string {
+		h = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(h)), "0x")
+		if v, err := codec.ParseFieldHex("0x" + h); err == nil {
+			return v.Text(16)
+		}
+		return h
+	}
+	a, b := norm(t.MyRootHex), norm(t.OppRootHex)
+	if a > b {
+		a, b = b, a
+	}
+	sum := sha256.Sum256([]byte(a + ":" + b))
+	return hex.EncodeToString(sum[:6])
+}
+
+// gameAttrs are the game id and turn number, read them with s.mu held and
+// log after unlocking
+func gameAttrs(t *turnState, g *gameState) []any {
+	var attrs []any
+	if id := gameID(t); id != "" {
+		attrs = append(attrs, "game_id", id)
+	}
+	if g != nil {
+		attrs = append(attrs, "turn", g.Shots+1)
+	}
+	return attrs
+}
+
+// logger is the request logger in ctx with the current game attributes
+func (s *Server) logger(ctx context.Context) *slog.Logger {
+	s.mu.RLock()
+	attrs := gameAttrs(s.turn, s.game)
+	s.mu.RUnlock()
+	return logging.From(ctx).With(attrs...)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// gorilla's upgrader asserts http.Hijacker directly
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.status = http.StatusSwitchingProtocols
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// WithRequestLog gives each request an id (the caller's X-Request-ID if it
+// sent a usable one), puts a logger with it in the request context and logs
+// the request when it's done. Reads are logged at debug, the peer polls
+// /v1/status every few seconds.
+func (s *Server) WithRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := logging.CleanRequestID(r.Header.Get("X-Request-ID"))
+		if id == "" {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		l := logging.From(r.Context()).With("request_id", id)
+		r = r.WithContext(logging.With(r.Context(), l))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		t0 := time.Now()
+		next.ServeHTTP(rec, r)
+
+		level := slog.LevelInfo
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			level = slog.LevelDebug
+		}
+		if rec.status >= 500 {
+			level = slog.LevelError
+		}
+		s.logger(r.Context()).Log(r.Context(), level, "http request",
+			"method", r.Method, "path", r.URL.Path, "status", rec.status,
+			"duration_ms", time.Since(t0).Milliseconds(), "remote", clientIP(r.RemoteAddr))
+	})
+}
+
+// UnaryLogger is WithRequestLog for gRPC, the id comes from x-request-id
+// metadata
+func (s *Server) UnaryLogger() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("x-request-id"); len(v) > 0 {
+				id = logging.CleanRequestID(v[0])
+			}
+		}
+		if id == "" {
+			id = logging.NewRequestID()
+		}
+		ctx = logging.With(ctx, logging.From(ctx).With("request_id", id))
+
+		t0 := time.Now()
+		resp, err := handler(ctx, req)
+		level := slog.LevelInfo
+		if strings.HasSuffix(info.FullMethod, "/Status") {
+			level = slog.LevelDebug
+		}
+		attrs := []any{"method", info.FullMethod, "duration_ms", time.Since(t0).Milliseconds()}
+		if err != nil {
+			attrs = append(attrs, "err", err)
+		}
+		s.logger(ctx).Log(ctx, level, "grpc request", attrs...)
+		return resp, err
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"battleship-zk/internal/logging"
+)
+
+// lockCheck records each log line and whether s.mu was free when it was
+// written
+type lockCheck struct {
+	s *Server
+
+	mu     sync.Mutex
+	lines  []string
+	locked []string
+}
+
+func (h *lockCheck) Enabled(context.Context, slog.Level) bool { return true }
+func (h *lockCheck) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *lockCheck) WithGroup(string) slog.Handler            { return h }
+
+func (h *lockCheck) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lines = append(h.lines, r.Message)
+	if !h.s.mu.TryLock() {
+		h.locked = append(h.locked, r.Message)
+		return nil
+	}
+	h.s.mu.Unlock()
+	return nil
+}
+
+func TestGameLogsOutsideLock(t *testing.T) {
+	s := newTestServer(t)
+	h := &lockCheck{s: s}
+	ctx := logging.With(context.Background(), slog.New(h))
+
+	s.updateTurn(ctx, func(t *turnState) { t.Decided, t.MyTurn = true, "me" })
+	s.updateTurn(ctx, func(t *turnState) { t.MyTurn = "opponent" })
+	s.updateGame(ctx, func(g *gameState) { g.Over, g.Winner = true, "me" })
+
+	if len(h.lines) != 3 {
+		t.Fatalf("logged %q, want two turn changes and game over", h.lines)
+	}
+	if len(h.locked) > 0 {
+		t.Errorf("logged with s.mu held: %q", h.locked)
+	}
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Disable()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"battleship-zk/internal/metrics"
+)
+
+// serverMetrics are the game metrics of one Server, on its own registry so
+// two servers in a process (examples/twoplayer) don't collide
+type serverMetrics struct {
+	reg       *prometheus.Registry
+	shots     *prometheus.CounterVec // side: received or fired, result: hit or miss
+	badProofs prometheus.Counter
+	peerPing  prometheus.Histogram
+}
+
+func newServerMetrics(s *Server) *serverMetrics {
+	m := &serverMetrics{
+		reg: prometheus.NewRegistry(),
+		shots: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "battleship_shots_total",
+			Help: "Shots answered (side=received) and our own shots verified (side=fired).",
+		}, []string{"side", "result"}),
+		badProofs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "battleship_invalid_proofs_total",
+			Help: "Answers from the peer rejected with bad_proof.",
+		}),
+		peerPing: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "battleship_peer_ping_seconds",
+			Help:    "Latency of successful /v1/status probes of the peer.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+		}),
+	}
+
+	gauge := func(name, help string, f func() float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, f)
+	}
+	counter := func(name, help string, f func() float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, f)
+	}
+	b2f := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	game := func() gameState { _, g, _, _ := s.snapshot(); return g }
+	turn := func() turnState { t, _, _, _ := s.snapshot(); return t }
+	load := func() (w, b, q int, rej, lim uint64) {
+		s.initLimits()
+		w, b, q, _, rej = s.pool.stats()
+		return w, b, q, rej, s.limiter.count()
+	}
+
+	m.reg.MustRegister(
+		m.shots, m.badProofs, m.peerPing,
+		gauge("battleship_game_hits_taken", "Hits the opponent scored on our board.", func() float64 { return float64(game().HitsTaken) }),
+		gauge("battleship_game_hits_dealt", "Hits we scored on the opponent's board.", func() float64 { return float64(game().HitsDealt) }),
+		gauge("battleship_game_over", "1 once the game is decided.", func() float64 { return b2f(game().Over) }),
+		gauge("battleship_game_won", "1 if the game is over and we won.", func() float64 { g := game(); return b2f(g.Over && g.Winner == "me") }),
+		gauge("battleship_turn_ready", "1 while both servers are up and the turn order is known.", func() float64 { return b2f(turn().Ready) }),
+		gauge("battleship_my_turn", "1 while it's our turn to fire.", func() float64 { return b2f(turn().MyTurn == "me") }),
+		gauge("battleship_committed", "1 once a board is committed.", func() float64 { return b2f(turn().MyRootHex != "") }),
+		gauge("battleship_peer_online", "1 if the last probe of the peer succeeded.", func() float64 {
+			h := s.mon.health()
+			return b2f(h != nil && h.Online)
+		}),
+		gauge("battleship_prove_workers", "Shots that may prove at once.", func() float64 { w, _, _, _, _ := load(); return float64(w) }),
+		gauge("battleship_prove_busy", "Shots proving right now.", func() float64 { _, b, _, _, _ := load(); return float64(b) }),
+		gauge("battleship_prove_queue_depth", "Shots waiting for a prover.", func() float64 { _, _, q, _, _ := load(); return float64(q) }),
+		counter("battleship_prove_rejected_total", "Shots turned away with prover_busy.", func() float64 { _, _, _, r, _ := load(); return float64(r) }),
+		counter("battleship_rate_limited_total", "Requests answered 429.", func() float64 { _, _, _, _, l := load(); return float64(l) }),
+	)
+	return m
+}
+
+// timedProbe wraps a peer probe to record its latency
+func (m *serverMetrics) timedProbe(probe func() (int64, error)) (int64, error) {
+	t0 := time.Now()
+	started, err := probe()
+	if err == nil {
+		metrics.Since(m.peerPing, t0)
+	}
+	return started, err
+}
+
+func (s *Server) handleMetrics() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{metrics.Registry, s.metrics.reg}, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"battleship-zk/pkg/api"
+)
+
+// every route the server registers and every code it can send must be in
+// the OpenAPI document
+func TestOpenAPICoversRoutesAndCodes(t *testing.T) {
+	s := New(t.TempDir(), "")
+	defer s.mon.close()
+	mux := http.NewServeMux()
+	s.Routes(mux)
+
+	paths := api.OpenAPI()["paths"].(map[string]any)
+	for pattern := range s.handlers() {
+		if _, ok := paths[pattern]; !ok {
+			t.Errorf("route %s is not in the OpenAPI document", pattern)
+		}
+	}
+	for path, item := range paths {
+		if _, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, path, nil)); pattern != path {
+			t.Errorf("documented path %s is served by %q", path, pattern)
+		}
+		for method, op := range item.(map[string]any) {
+			responses := op.(map[string]any)["responses"].(map[string]any)
+			for _, c := range api.ErrorCodes {
+				status, ok := api.ErrorStatus[c]
+				if !ok {
+					t.Fatalf("code %s has no status in api.ErrorStatus", c)
+				}
+				if opErr(c, "").status != status {
+					t.Errorf("code %s is sent with %d, documented %d", c, opErr(c, "").status, status)
+				}
+				resp, ok := responses[strconv.Itoa(status)].(map[string]any)
+				if !ok || !strings.Contains(resp["description"].(string), string(c)) {
+					t.Errorf("%s %s: code %s (%d) is not documented", method, path, c, status)
+				}
+			}
+		}
+	}
+	if len(api.ErrorStatus) != len(api.ErrorCodes) {
+		t.Errorf("api.ErrorStatus has %d codes, api.ErrorCodes %d", len(api.ErrorStatus), len(api.ErrorCodes))
+	}
+}
@@ -0,0 +1,490 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"battleship-zk/internal/app"
+	"battleship-zk/internal/codec"
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/metrics"
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+// the game operations below are shared by the HTTP handlers and the gRPC
+// service, failures come back as *opError so both can map them
+
+type opError struct {
+	status     int // http status
+	body       api.Error
+	retryAfter time.Duration // sent as Retry-After if set
+}
+
+func (e *opError) Error() string { return e.body.Message }
+
+// opErr sends code with its status from api.ErrorStatus
+func opErr(code api.ErrorCode, msg string) *opError {
+	return &opError{status: api.ErrorStatus[code], body: api.Error{Code: code, Message: msg}}
+}
+
+func turnErr(code api.ErrorCode, msg string, t *turnState) *opError {
+	e := opErr(code, msg)
+	e.body.Turn = &api.TurnState{MyTurn: t.MyTurn, Ready: t.Ready, Decided: t.Decided}
+	return e
+}
+
+func gameOverErr(g *gameState) *opError {
+	e := opErr(api.CodeGameOver, "game is over")
+	e.body.Game = &api.GameState{HitsTaken: g.HitsTaken, HitsDealt: g.HitsDealt, Over: g.Over, Winner: g.Winner}
+	return e
+}
+
+// badProof is a bad_proof error, counted in the metrics
+func (s *Server) badProof(msg string) *opError {
+	s.metrics.badProofs.Inc()
+	return opErr(api.CodeBadProof, msg)
+}
+
+var (
+	errMethod   = opErr(api.CodeMethodNotAllowed, "method not allowed")
+	errPeerCert = opErr(api.CodeUnauthenticated, "shoot needs the peer's client certificate")
+)
+
+func writeErr(w http.ResponseWriter, err error) {
+	var oe *opError
+	if !errors.As(err, &oe) {
+		oe = opErr(api.CodeInternal, err.Error())
+	}
+	if oe.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(oe.retryAfter.Seconds()))))
+	}
+	writeJSON(w, oe.status, oe.body)
+}
+
+func (s *Server) initBoard(strategy string, seed *uint64) (game.Board, error) {
+	if strategy == "" {
+		strategy = string(s.Strategy)
+	}
+	if _, err := game.ParseStrategy(strategy); err != nil {
+		return game.Board{}, opErr(api.CodeBadRequest, err.Error())
+	}
+	b, err := app.InitBoard(app.InitOptions{Strategy: strategy, Seed: seed})
+	if err != nil {
+		return game.Board{}, opErr(api.CodeInternal, err.Error())
+	}
+	return b, nil
+}
+
+// commit returns the salted root hex of the new commitment
+func (s *Server) commit(ctx context.Context, b game.Board, hashName string) (string, error) {
+	if hashName == "" {
+		hashName = string(s.Hash)
+	}
+	h, err := merkle.ParseHash(hashName)
+	if err != nil {
+		return "", opErr(api.CodeBadRequest, err.Error())
+	}
+	if err := b.Validate(); err != nil {
+		return "", opErr(api.CodeInvalidBoard, err.Error())
+	}
+	res, err := app.Commit(b, s.KeysDir, h)
+	if err != nil {
+		return "", opErr(api.CodeInternal, err.Error())
+	}
+
+	// a plain secret file holds the board, only written when asked for
+	if s.SecretPath != "" && (len(s.Passphrase) > 0 || s.PersistPlaintext) {
+		if err := codec.SaveSecret(s.SecretPath, &res.Secret, s.Passphrase); err != nil {
+			return "", opErr(api.CodeInternal, "failed to save secret: "+err.Error())
+		}
+	}
+
+	s.mu.Lock()
+	s.sec = &res.Secret
+	s.mu.Unlock()
+
+	rootHex, _ := computeRootHex(&res.Secret)
+	s.updateTurn(ctx, func(t *turnState) { t.MyRootHex = rootHex })
+	s.logger(ctx).Info("board committed", "root", rootHex, "hash", h.Normalize())
+	return rootHex, nil
+}
+
+type shootResult struct {
+	Payload codec.ShotProofPayload
+	Bit     uint8
+	RootHex string
+	VK      []byte // nil if the vk file can't be read
+}
+
+// shoot answers the opponent's shot at (row, col) with a proof, proving
+// stops early if ctx is done (the caller went away)
+func (s *Server) shoot(ctx context.Context, row, col int) (*shootResult, error) {
+	if row < 0 || row > 9 || col < 0 || col > 9 {
+		return nil, opErr(api.CodeBadRequest, "row/col out of range")
+	}
+	// we only let shoot if it not my turn
+	t, err := s.loadTurn()
+	if err != nil {
+		return nil, opErr(api.CodeInternal, "failed to read turn state")
+	}
+	if !t.Ready || t.MyTurn != "opponent" {
+		return nil, turnErr(api.CodeNotYourTurn, "not allowed: it's not opponent's turn to shoot", t)
+	}
+	if g, gErr := s.loadGame(); gErr == nil && g.Over {
+		return nil, gameOverErr(g)
+	}
+
+	// this to prevent duplicate shots on same cell
+	k := shotKey(row, col)
+	s.mu.Lock()
+	if s.shotsTried == nil {
+		s.shotsTried = make(map[string]bool)
+	}
+	if s.shotsTried[k] {
+		s.mu.Unlock()
+		return nil, turnErr(api.CodeCellTargeted, fmt.Sprintf("cell (%d,%d) already targeted", row, col), t)
+	}
+	// one shot per turn, however many prove workers there are: the turn
+	// is taken here and given back when the shot is answered or dropped
+	if s.shooting {
+		s.mu.Unlock()
+		return nil, turnErr(api.CodeNotYourTurn, "not allowed: a shot is already being answered this turn", t)
+	}
+	s.shooting = true
+	s.shotsTried[k] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.shooting = false
+		s.mu.Unlock()
+	}()
+
+	sec, err := s.currentSecret()
+	if err != nil {
+		s.mu.Lock()
+		delete(s.shotsTried, k)
+		s.mu.Unlock()
+		return nil, opErr(api.CodeNotCommitted, err.Error())
+	}
+
+	// proving is the expensive part, at most ProveWorkers at once
+	s.initLimits()
+	release, err := s.pool.acquire(ctx)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.shotsTried, k)
+		s.mu.Unlock()
+		if errors.Is(err, errProverBusy) {
+			e := opErr(api.CodeProverBusy, "prover busy, try again later")
+			e.retryAfter = proveRetryAfter
+			return nil, e
+		}
+		return nil, opErr(api.CodeUnavailable, "shot abandoned: "+err.Error())
+	}
+	// held until the turn has moved on
+	defer release()
+	// a shot that waited may have lost the turn to the one before it
+	if t2, _ := s.loadTurn(); t2.MyTurn != "opponent" {
+		s.mu.Lock()
+		delete(s.shotsTried, k)
+		s.mu.Unlock()
+		return nil, turnErr(api.CodeNotYourTurn, "not allowed: it's not opponent's turn to shoot", t2)
+	}
+	l := s.logger(ctx)
+	t0 := time.Now()
+	res, err := app.Shoot(ctx, *sec, s.KeysDir, row, col)
+	if err != nil {
+		l.Warn("shot not answered", "row", row, "col", col, "err", err)
+		s.mu.Lock()
+		delete(s.shotsTried, k)
+		s.mu.Unlock()
+		if ctx.Err() != nil {
+			return nil, opErr(api.CodeUnavailable, "shot abandoned: "+ctx.Err().Error())
+		}
+		return nil, opErr(api.CodeInternal, err.Error())
+	}
+
+	// this is just for coloring in the UI
+	ev := s.recordShot(row, col, res.Bit)
+	s.metrics.shots.WithLabelValues("received", metrics.HitMiss(res.Bit)).Inc()
+
+	l.Info("shot answered", "row", row, "col", col, "hit", res.Bit, "prove_ms", time.Since(t0).Milliseconds())
+	g := s.updateGame(ctx, func(g *gameState) {
+		g.Shots++
+		if res.Bit == 1 && !g.Over {
+			g.HitsTaken++
+			if g.HitsTaken >= api.ShipCells {
+				g.Over = true
+				g.Winner = "opponent"
+			}
+		}
+	})
+	s.events.publish(api.Event{Type: api.EventDefense, Shot: &ev, Game: apiGame(g)})
+
+	rootHex, err := computeRootHex(sec)
+	if err != nil {
+		return nil, opErr(api.CodeInternal, err.Error())
+	}
+
+	s.updateTurn(ctx, func(t *turnState) { t.MyTurn = "me" })
+
+	return &shootResult{Payload: res.Payload, Bit: res.Bit, RootHex: rootHex, VK: s.loadVK()}, nil
+}
+
+// verify checks the proof the opponent answered our shot with, against
+// their root and verifying key
+func (s *Server) verify(ctx context.Context, rootHex string, payload codec.ShotProofPayload, rawVK []byte) (*app.VerifyResult, error) {
+	t, err := s.loadTurn()
+	if err != nil {
+		return nil, opErr(api.CodeInternal, "failed to read turn state")
+	}
+	if !t.Ready || t.MyTurn != "me" {
+		return nil, turnErr(api.CodeNotYourTurn, "not allowed: it's not our turn to verify an attack", t)
+	}
+	if g, gErr := s.loadGame(); gErr == nil && g.Over {
+		return nil, gameOverErr(g)
+	}
+
+	if len(rawVK) == 0 {
+		return nil, opErr(api.CodeBadRequest, "verifying key required")
+	}
+	if strings.TrimSpace(rootHex) == "" {
+		return nil, opErr(api.CodeBadRequest, "rootHex required")
+	}
+	h := strings.TrimSpace(rootHex)
+	if !strings.HasPrefix(h, "0x") && !strings.HasPrefix(h, "0X") {
+		h = "0x" + h
+	}
+	rootInt, err := codec.ParseFieldHex(h)
+	if err != nil {
+		return nil, opErr(api.CodeBadRequest, "invalid rootHex: "+err.Error())
+	}
+
+	// i have this because the verify function i have now expects a path and not raw bytes (will change later)
+	f, err := os.CreateTemp("", "vk-*.vk")
+	if err != nil {
+		return nil, opErr(api.CodeInternal, err.Error())
+	}
+	if _, err := f.Write(rawVK); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, opErr(api.CodeInternal, err.Error())
+	}
+	_ = f.Close()
+	vkPath := f.Name()
+	defer os.Remove(vkPath)
+
+	l := s.logger(ctx)
+	res, err := app.VerifyWithRoot(vkPath, rootInt, payload)
+	if err != nil {
+		l.Warn("proof rejected", "row", payload.Public.Row, "col", payload.Public.Col, "err", err)
+		return nil, s.badProof(err.Error())
+	}
+	l.Info("shot verified", "row", payload.Public.Row, "col", payload.Public.Col, "hit", res.Hit)
+
+	if res.Valid {
+		s.updateTurn(ctx, func(t *turnState) { t.MyTurn = "opponent" })
+		s.metrics.shots.WithLabelValues("fired", metrics.HitMiss(res.Hit)).Inc()
+	}
+
+	// Attack-side game state update on hit
+	g := s.updateGame(ctx, func(g *gameState) {
+		if res.Valid {
+			g.Shots++
+		}
+		if res.Hit == 1 && !g.Over {
+			g.HitsDealt++
+			if g.HitsDealt >= api.ShipCells {
+				g.Over = true
+				g.Winner = "me"
+			}
+		}
+	})
+	s.events.publish(api.Event{
+		Type: api.EventVerified,
+		Shot: &api.ShotEvent{Row: int(payload.Public.Row), Col: int(payload.Public.Col), Bit: res.Hit, At: time.Now().UnixMilli()},
+		Game: apiGame(g),
+	})
+	return res, nil
+}
+
+// setPeer registers the opponent, selfID is our own base url as the peer
+// sees it and is only used while MyID is still unknown. A root or vk left
+// empty is asked from the peer itself.
+func (s *Server) setPeer(ctx context.Context, baseURL, rootHex, vkB64, selfID string) error {
+	if strings.TrimSpace(baseURL) == "" {
+		return opErr(api.CodeBadRequest, "missing baseUrl")
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if rootHex == "" || vkB64 == "" {
+		pctx, cancel := context.WithTimeout(ctx, peerProbeTimeout)
+		st, err := s.peerClient(baseURL).Status(pctx)
+		cancel()
+		if err == nil {
+			if rootHex == "" {
+				rootHex = st.MyRootHex
+			}
+			if vkB64 == "" {
+				vkB64 = st.VKB64
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.peer = &api.Peer{
+		BaseURL: baseURL,
+		RootHex: rootHex,
+		VKB64:   vkB64,
+	}
+	s.mu.Unlock()
+
+	// one probe right away, outside s.mu, so a live peer decides the turn
+	// order before we answer
+	s.mon.setTarget(baseURL)
+	s.mon.check(context.Background())
+
+	s.updateTurn(ctx, func(t *turnState) {
+		if strings.TrimSpace(t.MyID) == "" {
+			t.MyID = selfID
+		}
+		t.OppID = baseURL
+		if strings.TrimSpace(rootHex) != "" {
+			t.OppRootHex = rootHex
+		}
+	})
+	online, _ := s.mon.cached(baseURL)
+	s.logger(ctx).Info("peer registered", "peer", baseURL, "root", rootHex, "online", online)
+	return nil
+}
+
+// SetPeer registers the opponent's server at startup (serve --peer). Its
+// root and vk are fetched if it's up, or taken from its first answer. A
+// peer with the same url restored by LoadState is kept as it is.
+func (s *Server) SetPeer(ctx context.Context, baseURL, selfURL string) error {
+	s.mu.RLock()
+	same := s.peer != nil && s.peer.BaseURL == strings.TrimRight(baseURL, "/")
+	s.mu.RUnlock()
+	if same {
+		return nil
+	}
+	return s.setPeer(ctx, baseURL, "", "", selfURL)
+}
+
+func sameRoot(a, b string) bool {
+	parse := func(h string) string {
+		h = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(h)), "0x")
+		if v, err := codec.ParseFieldHex("0x" + h); err == nil {
+			return v.String()
+		}
+		return h
+	}
+	return parse(a) == parse(b)
+}
+
+func (s *Server) peerHTTP() *http.Client {
+	if s.PeerHTTP != nil {
+		return s.PeerHTTP
+	}
+	return http.DefaultClient
+}
+
+func (s *Server) peerClient(baseURL string) *client.Client {
+	return client.New(baseURL, client.WithHTTPClient(s.peerHTTP()))
+}
+
+// fire is our own shot: the peer's server is asked for an answer over our
+// peer client and the proof is checked here against the root they
+// registered with, so the browser never talks to the opponent directly
+func (s *Server) fire(ctx context.Context, row, col int) (*app.VerifyResult, error) {
+	if row < 0 || row > 9 || col < 0 || col > 9 {
+		return nil, opErr(api.CodeBadRequest, "row/col out of range")
+	}
+	t, err := s.loadTurn()
+	if err != nil {
+		return nil, opErr(api.CodeInternal, "failed to read turn state")
+	}
+	if !t.Ready || t.MyTurn != "me" {
+		return nil, turnErr(api.CodeNotYourTurn, "not allowed: it's not our turn to shoot", t)
+	}
+	if g, gErr := s.loadGame(); gErr == nil && g.Over {
+		return nil, gameOverErr(g)
+	}
+	s.mu.RLock()
+	var peer api.Peer
+	if s.peer != nil {
+		peer = *s.peer
+	}
+	s.mu.RUnlock()
+	if peer.BaseURL == "" {
+		return nil, opErr(api.CodeBadRequest, "no peer registered")
+	}
+
+	l := s.logger(ctx)
+	l.Debug("firing at peer", "peer", peer.BaseURL, "row", row, "col", col)
+	shot, err := s.peerClient(peer.BaseURL).Shoot(ctx, row, col)
+	if err != nil {
+		l.Warn("peer did not answer the shot", "peer", peer.BaseURL, "row", row, "col", col, "err", err)
+		var ce *client.Error
+		switch {
+		case errors.As(err, &ce) && ce.Body.Code != "":
+			// the peer's own answer, e.g. not_your_turn on their side
+			return nil, &opError{status: ce.StatusCode, body: ce.Body}
+		case ctx.Err() != nil:
+			return nil, opErr(api.CodeUnavailable, "shot abandoned: "+ctx.Err().Error())
+		}
+		return nil, opErr(api.CodePeerUnavailable, err.Error())
+	}
+
+	// the root must stay the one the peer committed to, a new root would
+	// mean a new board
+	rootHex := peer.RootHex
+	if rootHex == "" {
+		rootHex = shot.RootHex
+	} else if !sameRoot(rootHex, shot.RootHex) {
+		return nil, s.badProof(fmt.Sprintf("peer answered for root %s, registered %s", shot.RootHex, rootHex))
+	}
+	vkB64 := peer.VKB64
+	if vkB64 == "" {
+		vkB64 = shot.VKB64
+	}
+	rawVK, err := base64.StdEncoding.DecodeString(vkB64)
+	if err != nil {
+		return nil, s.badProof("peer sent an invalid vk")
+	}
+	payload, err := codec.DecodeShotProof(shot.Payload)
+	if err != nil {
+		return nil, s.badProof("bad payload: " + err.Error())
+	}
+	if int(payload.Public.Row) != row || int(payload.Public.Col) != col {
+		return nil, s.badProof("peer answered for another cell")
+	}
+	res, err := s.verify(ctx, rootHex, *payload, rawVK)
+	if err != nil {
+		return nil, err
+	}
+	if peer.RootHex == "" {
+		// the peer had not committed when it was registered, hold it to
+		// this root from now on
+		s.mu.Lock()
+		if s.peer != nil && s.peer.BaseURL == peer.BaseURL {
+			// status readers hold the old pointer, swap in a copy
+			p := *s.peer
+			p.RootHex, p.VKB64 = rootHex, vkB64
+			s.peer = &p
+		}
+		s.mu.Unlock()
+		s.updateTurn(ctx, func(t *turnState) { t.OppRootHex = rootHex })
+	}
+	return res, nil
+}
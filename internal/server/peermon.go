@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"battleship-zk/pkg/api"
+)
+
+const (
+	peerProbeTimeout = 1500 * time.Millisecond
+	peerPollOnline   = 2 * time.Second
+	peerBackoffMin   = 500 * time.Millisecond
+	peerBackoffMax   = 30 * time.Second
+	peerHistoryLen   = 20
+	// failed probes in a row before an online peer counts as offline, so a
+	// single slow answer (a busy or GC-paused peer) doesn't stop the game
+	peerOfflineAfter = 2
+)
+
+// peerMonitor checks the opponent's /v1/status in the background so turn
+// handling only reads a cached result and never waits on the network while
+// holding s.mu
+type peerMonitor struct {
+	mu       sync.Mutex
+	url      string
+	online   bool
+	started  int64
+	lastSeen time.Time
+	failures int // consecutive
+	history  []api.PeerCheck
+
+	probe    func(ctx context.Context, url string) (startedAt int64, err error)
+	onChange func() // called without m.mu when online or started changes
+	kick     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newPeerMonitor(probe func(ctx context.Context, url string) (int64, error), onChange func()) *peerMonitor {
+	return &peerMonitor{
+		probe:    probe,
+		onChange: onChange,
+		kick:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// setTarget switches to a new peer url, forgetting what we knew of the old one
+func (m *peerMonitor) setTarget(url string) {
+	m.mu.Lock()
+	if url != m.url {
+		m.url = url
+		m.online, m.started, m.failures = false, 0, 0
+		m.lastSeen = time.Time{}
+		m.history = nil
+	}
+	m.mu.Unlock()
+	select {
+	case m.kick <- struct{}{}:
+	default:
+	}
+}
+
+// cached reports the last known state of the peer at url
+func (m *peerMonitor) cached(url string) (online bool, startedAt int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if url == "" || normalizeID(url) != normalizeID(m.url) {
+		return false, 0
+	}
+	return m.online, m.started
+}
+
+func (m *peerMonitor) health() *api.PeerHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.url == "" {
+		return nil
+	}
+	h := &api.PeerHealth{
+		Online:    m.online,
+		StartedAt: m.started,
+		Failures:  m.failures,
+		History:   append([]api.PeerCheck(nil), m.history...),
+	}
+	if !m.lastSeen.IsZero() {
+		h.LastSeen = m.lastSeen.UnixMilli()
+	}
+	return h
+}
+
+// check probes the current target once and records the result
+func (m *peerMonitor) check(ctx context.Context) {
+	m.mu.Lock()
+	url := m.url
+	m.mu.Unlock()
+	if url == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, peerProbeTimeout)
+	t0 := time.Now()
+	started, err := m.probe(ctx, url)
+	cancel()
+
+	m.mu.Lock()
+	if url != m.url {
+		// target changed while probing
+		m.mu.Unlock()
+		return
+	}
+	wasOnline, wasStarted := m.online, m.started
+	c := api.PeerCheck{At: t0.UnixMilli(), OK: err == nil, LatencyMs: time.Since(t0).Milliseconds()}
+	if err != nil {
+		c.Error = err.Error()
+		m.failures++
+		m.online = m.online && m.failures < peerOfflineAfter
+	} else {
+		m.online, m.started = true, started
+		m.failures = 0
+		m.lastSeen = time.Now()
+	}
+	m.history = append(m.history, c)
+	if len(m.history) > peerHistoryLen {
+		m.history = m.history[len(m.history)-peerHistoryLen:]
+	}
+	changed := m.online != wasOnline || m.started != wasStarted
+	m.mu.Unlock()
+
+	if changed && m.onChange != nil {
+		m.onChange()
+	}
+}
+
+func (m *peerMonitor) next() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures == 0 {
+		return peerPollOnline
+	}
+	d := peerBackoffMin << min(m.failures-1, 8)
+	return min(d, peerBackoffMax)
+}
+
+func (m *peerMonitor) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-m.stop
+		cancel()
+	}()
+
+	for {
+		m.mu.Lock()
+		idle := m.url == ""
+		m.mu.Unlock()
+
+		var wait <-chan time.Time
+		if !idle {
+			m.check(ctx)
+			wait = time.After(m.next())
+		}
+		select {
+		case <-wait:
+		case <-m.kick:
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *peerMonitor) close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// probePeer reads startedAt from the peer's /v1/status
+func probePeer(ctx context.Context, hc *http.Client, baseURL string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/v1/status", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("peer status: HTTP %d", resp.StatusCode)
+	}
+	var st api.Status
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return 0, err
+	}
+	if st.StartedAt <= 0 {
+		return 0, errors.New("peer status has no startedAt")
+	}
+	return st.StartedAt, nil
+}
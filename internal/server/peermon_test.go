@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"battleship-zk/pkg/api"
+)
+
+// hangingPeer is a peer that accepts connections and never answers, each
+// request arriving is signalled on the returned channel
+func hangingPeer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	arrived := make(chan struct{}, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, arrived
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}, d time.Duration, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(d):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func probeMonitor(srv *httptest.Server) *peerMonitor {
+	m := newPeerMonitor(func(ctx context.Context, url string) (int64, error) {
+		return probePeer(ctx, srv.Client(), url)
+	}, nil)
+	m.setTarget(srv.URL)
+	return m
+}
+
+// a probe hanging on the peer must not hold up turn handling or /v1/status
+func TestPeerMonitorDoesNotBlock(t *testing.T) {
+	srv, arrived := hangingPeer(t)
+	s := New(t.TempDir(), "")
+	t.Cleanup(s.mon.close)
+
+	s.mu.Lock()
+	s.peer = &api.Peer{BaseURL: srv.URL}
+	s.turn.MyID, s.turn.OppID = "http://me.example", srv.URL
+	s.mu.Unlock()
+	s.mon.setTarget(srv.URL)
+	waitFor(t, arrived, 2*time.Second, "the first probe")
+
+	done := make(chan struct{})
+	var turn *turnState
+	var st api.Status
+	go func() {
+		defer close(done)
+		turn = s.updateTurn(context.Background(), func(*turnState) {})
+		st = s.statusPayload()
+	}()
+	waitFor(t, done, 200*time.Millisecond, "updateTurn and statusPayload while a probe hangs")
+
+	if turn.Ready || turn.Decided {
+		t.Errorf("turn %+v decided against a peer that never answered", turn)
+	}
+	if st.PeerHealth == nil || st.PeerHealth.Online {
+		t.Errorf("peer health = %+v, want offline", st.PeerHealth)
+	}
+}
+
+func TestPeerMonitorBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, peerPollOnline},
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{6, 16 * time.Second},
+		{7, peerBackoffMax},
+		{9, peerBackoffMax},
+		{1000, peerBackoffMax},
+	}
+	m := newPeerMonitor(nil, nil)
+	for _, tc := range tests {
+		m.failures = tc.failures
+		if got := m.next(); got != tc.want {
+			t.Errorf("next() after %d failures = %v, want %v", tc.failures, got, tc.want)
+		}
+	}
+}
+
+// failed probes grow the wait up to peerBackoffMax and keep the last
+// peerHistoryLen results
+func TestPeerMonitorFailures(t *testing.T) {
+	srv, _ := hangingPeer(t)
+	m := probeMonitor(srv)
+
+	prev := time.Duration(0)
+	const checks = peerHistoryLen + 5
+	for i := 1; i <= checks; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		m.check(ctx)
+		cancel()
+
+		d := m.next()
+		if d < prev || d > peerBackoffMax {
+			t.Fatalf("check %d: next() = %v after %v", i, d, prev)
+		}
+		prev = d
+		h := m.health()
+		if h.Failures != i || h.Online {
+			t.Fatalf("check %d: health %+v", i, h)
+		}
+		if want := min(i, peerHistoryLen); len(h.History) != want {
+			t.Fatalf("check %d: %d history entries, want %d", i, len(h.History), want)
+		}
+	}
+	if prev != peerBackoffMax {
+		t.Errorf("backoff stopped at %v, want %v", prev, peerBackoffMax)
+	}
+	for _, c := range m.health().History {
+		if c.OK || c.Error == "" {
+			t.Errorf("history entry %+v for a failed probe", c)
+		}
+	}
+}
+
+func TestPeerMonitorCloseStopsRun(t *testing.T) {
+	srv, arrived := hangingPeer(t)
+	m := probeMonitor(srv)
+
+	stopped := make(chan struct{})
+	go func() {
+		m.run()
+		close(stopped)
+	}()
+	waitFor(t, arrived, 2*time.Second, "the first probe")
+
+	// well under peerProbeTimeout, so the hanging probe must be cancelled
+	m.close()
+	waitFor(t, stopped, peerProbeTimeout/2, "run to return")
+	m.close()
+}
+
+// an online peer stays online through peerOfflineAfter-1 failed probes
+func TestPeerMonitorOfflineAfter(t *testing.T) {
+	results := []error{nil, errors.New("slow"), nil, errors.New("slow"), errors.New("slow"), nil}
+	want := []bool{true, true, true, true, false, true}
+	i := 0
+	changes := 0
+	m := newPeerMonitor(func(context.Context, string) (int64, error) {
+		err := results[i]
+		i++
+		return 1700000000000, err
+	}, func() { changes++ })
+	m.setTarget("http://peer.example")
+	for n := range results {
+		m.check(context.Background())
+		if online, _ := m.cached("http://peer.example"); online != want[n] {
+			t.Fatalf("after probe %d (%v): online = %v, want %v", n, results[n], online, want[n])
+		}
+	}
+	// online, offline, online again
+	if changes != 3 {
+		t.Errorf("onChange called %d times, want 3", changes)
+	}
+}
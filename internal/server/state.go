@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"battleship-zk/pkg/api"
+)
+
+// savedState is the game progress written to StatePath on shutdown, the
+// board itself lives in the secret file
+type savedState struct {
+	StartedAt  int64          `json:"startedAt"`
+	Turn       turnState      `json:"turn"`
+	Game       gameState      `json:"game"`
+	Peer       *api.Peer      `json:"peer,omitempty"`
+	LastShot   *api.ShotEvent `json:"lastShot,omitempty"`
+	ShotsTried []string       `json:"shotsTried,omitempty"`
+}
+
+// SaveState writes the game progress to StatePath, if set
+func (s *Server) SaveState() error {
+	if s.StatePath == "" {
+		return nil
+	}
+	s.mu.RLock()
+	st := savedState{
+		StartedAt: s.startAt,
+		Turn:      *s.turn,
+		Game:      *s.game,
+		Peer:      s.peer,
+		LastShot:  s.lastEvt,
+	}
+	for k := range s.shotsTried {
+		st.ShotsTried = append(st.ShotsTried, k)
+	}
+	s.mu.RUnlock()
+	sort.Strings(st.ShotsTried)
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write then rename so a crash mid-write keeps the old file
+	tmp, err := os.CreateTemp(filepath.Dir(s.StatePath), ".state-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.StatePath)
+}
+
+// LoadState restores the progress saved by SaveState. Call it after
+// LoadSecret, state saved for another commitment is ignored.
+func (s *Server) LoadState() error {
+	if s.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st savedState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("load %s: %w", s.StatePath, err)
+	}
+
+	s.mu.Lock()
+	if s.turn == nil || st.Turn.MyRootHex == "" || st.Turn.MyRootHex != s.turn.MyRootHex {
+		s.mu.Unlock()
+		slog.Info("saved state is for another commitment, ignored", "path", s.StatePath)
+		return nil
+	}
+	s.startAt = st.StartedAt
+	s.turn = &st.Turn
+	s.game = &st.Game
+	s.peer = st.Peer
+	s.lastEvt = st.LastShot
+	s.shotsTried = make(map[string]bool, len(st.ShotsTried))
+	for _, k := range st.ShotsTried {
+		s.shotsTried[k] = true
+	}
+	s.mu.Unlock()
+
+	if st.Peer != nil {
+		s.mon.setTarget(st.Peer.BaseURL)
+	}
+	s.logger(context.Background()).Info("game state restored", "path", s.StatePath, "shots_tried", len(st.ShotsTried))
+	return nil
+}
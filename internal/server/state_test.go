@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"battleship-zk/internal/codec"
+	"battleship-zk/pkg/api"
+)
+
+// newTestServer is a server whose peer monitor stops with the test
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := New(t.TempDir(), "")
+	t.Cleanup(s.mon.close)
+	return s
+}
+
+const midGameShots = 17
+
+// midGame puts s in the middle of a game against a peer that is down
+func midGame(s *Server, rootHex string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startAt = 1700000000000
+	s.turn = &turnState{MyTurn: "opponent", MyRootHex: rootHex, OppRootHex: "0x2", Ready: true, Decided: true, MyID: "http://me.example", OppID: "http://127.0.0.1:1"}
+	s.game = &gameState{HitsTaken: 3, HitsDealt: 5, Shots: midGameShots}
+	s.peer = &api.Peer{BaseURL: "http://127.0.0.1:1", RootHex: "0x2"}
+	s.lastEvt = &api.ShotEvent{Row: 4, Col: 2, Bit: 1, N: 9, At: 1700000001000}
+	s.shotsTried = map[string]bool{shotKey(4, 2): true, shotKey(0, 0): true}
+}
+
+func TestCloseSavesState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	a := newTestServer(t)
+	a.StatePath = path
+	midGame(a, "0xabc")
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Close did not write the state file: %v", err)
+	}
+
+	// LoadSecret sets the root before LoadState runs
+	b := newTestServer(t)
+	b.StatePath = path
+	b.turn.MyRootHex = "0xabc"
+	if err := b.LoadState(); err != nil {
+		t.Fatal(err)
+	}
+	if b.startAt != a.startAt {
+		t.Errorf("startAt = %d, want %d", b.startAt, a.startAt)
+	}
+	for name, pair := range map[string][2]any{
+		"turn":       {b.turn, a.turn},
+		"game":       {b.game, a.game},
+		"peer":       {b.peer, a.peer},
+		"last shot":  {b.lastEvt, a.lastEvt},
+		"shotsTried": {b.shotsTried, a.shotsTried},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Errorf("%s = %+v, want %+v", name, pair[0], pair[1])
+		}
+	}
+	if h := b.mon.health(); h == nil {
+		t.Error("restored peer is not monitored")
+	}
+}
+
+func TestLoadStateIgnoresOtherRoot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	a := newTestServer(t)
+	a.StatePath = path
+	midGame(a, "0xabc")
+	if err := a.SaveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, root := range []string{"0xdef", ""} {
+		b := newTestServer(t)
+		b.StatePath = path
+		b.turn.MyRootHex = root
+		startAt := b.startAt
+		if err := b.LoadState(); err != nil {
+			t.Fatal(err)
+		}
+		if b.startAt != startAt || b.game.Shots != 0 || b.peer != nil || len(b.shotsTried) != 0 {
+			t.Errorf("root %q: state saved for 0xabc was restored", root)
+		}
+	}
+}
+
+// a shot whose caller went away answers 503 and the cell can be shot again
+func TestShootCancelled(t *testing.T) {
+	tests := []struct {
+		name string
+		busy bool // all prove workers taken, the shot waits in the queue
+	}{
+		{"while proving", false},
+		{"while queued", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t)
+			midGame(s, "0xabc")
+			s.shotsTried = map[string]bool{}
+			s.sec = &codec.Secret{}
+			s.initLimits()
+			if tc.busy {
+				for range cap(s.pool.slots) {
+					s.pool.slots <- struct{}{}
+				}
+			}
+			mux := http.NewServeMux()
+			s.Routes(mux)
+
+			for try := 0; try < 2; try++ {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/v1/shoot", strings.NewReader(`{"row":3,"col":7}`))
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, req)
+
+				var body api.Error
+				_ = json.Unmarshal(rec.Body.Bytes(), &body)
+				if rec.Code != http.StatusServiceUnavailable || body.Code != api.CodeUnavailable {
+					t.Fatalf("try %d: %d %+v, want 503 %s", try, rec.Code, body, api.CodeUnavailable)
+				}
+				s.mu.RLock()
+				tried := s.shotsTried[shotKey(3, 7)]
+				s.mu.RUnlock()
+				if tried {
+					t.Fatalf("try %d: abandoned shot still marks the cell as targeted", try)
+				}
+			}
+		})
+	}
+}
+
+func TestCloseEndsWebSockets(t *testing.T) {
+	s := newTestServer(t)
+	mux := http.NewServeMux()
+	s.Routes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var conns []*websocket.Conn
+	for range 2 {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var ev api.Event
+		if err := conn.ReadJSON(&ev); err != nil || ev.Type != api.EventStatus {
+			t.Fatalf("first event %+v (%v), want the status", ev, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i, conn := range conns {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			t.Fatalf("stream %d still open after Close", i)
+		}
+		if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+			t.Fatalf("stream %d was not ended by Close", i)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+// tlsPlayer is one player's server listening with its own self-signed cert
+type tlsPlayer struct {
+	s    *Server
+	srv  *httptest.Server
+	opts TLSOptions
+	url  string
+}
+
+func newTLSPlayer(t *testing.T, keysDir, name string) *tlsPlayer {
+	t.Helper()
+	dir := t.TempDir()
+	p := &tlsPlayer{s: newTestServer(t)}
+	p.s.KeysDir = keysDir
+	p.opts = TLSOptions{CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}
+	if err := GenerateSelfSigned(p.opts.CertFile, p.opts.KeyFile, name, []string{"127.0.0.1"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	p.s.Routes(mux)
+	p.srv = httptest.NewUnstartedServer(mux)
+	p.url = "https://" + p.srv.Listener.Addr().String()
+	p.s.PublicURL = p.url
+	return p
+}
+
+// trust makes p and q each other's peer CA and starts both servers
+func trust(t *testing.T, p, q *tlsPlayer) {
+	t.Helper()
+	p.opts.PeerCAFile, q.opts.PeerCAFile = q.opts.CertFile, p.opts.CertFile
+	for _, x := range []*tlsPlayer{p, q} {
+		cfg, err := x.opts.ServerTLS()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x.s.PeerHTTP, err = x.opts.PeerClient(); err != nil {
+			t.Fatal(err)
+		}
+		x.s.RequirePeerCert = true
+		x.srv.TLS = cfg
+		x.srv.StartTLS()
+		t.Cleanup(x.srv.Close)
+	}
+}
+
+// browser is the player's own UI: it trusts the server's cert and has no
+// client cert of its own
+func (p *tlsPlayer) browser(t *testing.T) *client.Client {
+	t.Helper()
+	hc, err := TLSOptions{PeerCAFile: p.opts.CertFile}.PeerClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.New(p.url, client.WithHTTPClient(hc))
+}
+
+func TestMutualTLS(t *testing.T) {
+	ctx := context.Background()
+	keys := t.TempDir()
+	a, b := newTLSPlayer(t, keys, "alice"), newTLSPlayer(t, keys, "bob")
+	trust(t, a, b)
+
+	seed := uint64(11)
+	for _, p := range []*tlsPlayer{a, b} {
+		c := p.browser(t)
+		board, err := c.Init(ctx, api.InitRequest{Seed: &seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Commit(ctx, api.CommitRequest{Board: *board}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the event stream dials with the client's TLS settings too
+	evCtx, stopEvents := context.WithCancel(ctx)
+	defer stopEvents()
+	events, err := a.browser(t).Events(evCtx)
+	if err != nil {
+		t.Fatalf("events over TLS: %v", err)
+	}
+	if ev := <-events; ev.Type != api.EventStatus {
+		t.Fatalf("first event %+v, want the status", ev)
+	}
+
+	// shoot is only answered over mTLS
+	var ce *client.Error
+	_, err = b.browser(t).Shoot(ctx, 0, 0)
+	if !errors.As(err, &ce) || ce.StatusCode != http.StatusUnauthorized || ce.Body.Code != api.CodeUnauthenticated {
+		t.Fatalf("shoot without a client cert: %v, want 401 %s", err, api.CodeUnauthenticated)
+	}
+
+	// setPeer fetches the peer's root and the probe reaches it, both over
+	// PeerClient
+	var st *api.Status
+	for _, pair := range [][2]*tlsPlayer{{a, b}, {b, a}} {
+		if st, err = pair[0].browser(t).SetPeer(ctx, api.Peer{BaseURL: pair[1].url}); err != nil {
+			t.Fatal(err)
+		}
+		if st.PeerHealth == nil || !st.PeerHealth.Online {
+			t.Fatalf("peer health %+v, the probe did not get through", st.PeerHealth)
+		}
+		if st.OppRootHex == "" {
+			t.Fatal("peer root was not fetched")
+		}
+	}
+	if !st.Turn.Decided {
+		t.Fatal("turn order not decided with both peers online")
+	}
+
+	attacker := b
+	if st.Turn.MyTurn != "me" {
+		attacker = a
+	}
+	res, err := attacker.browser(t).FireAtPeer(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatal("fire: proof from the peer rejected")
+	}
+}
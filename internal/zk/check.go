@@ -0,0 +1,21 @@
+package zk
+
+import (
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+)
+
+// CheckShot runs the constraint solver on a full assignment without proving.
+// A nil error means a prover holding this witness would produce a valid proof.
+// The circuit is compiled for assign.Hash.
+func CheckShot(assign *ShotCircuit) error {
+	cs, err := CompileShot(assign.Hash)
+	if err != nil {
+		return err
+	}
+	wit, err := frontend.NewWitness(assign, ecc.BN254.ScalarField())
+	if err != nil {
+		return err
+	}
+	return cs.IsSolved(wit)
+}
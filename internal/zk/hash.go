@@ -0,0 +1,47 @@
+package zk
+
+import (
+	"fmt"
+	"path/filepath"
+
+	bnposeidon2 "github.com/consensys/gnark-crypto/ecc/bn254/fr/poseidon2"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/permutation/poseidon2"
+
+	"battleship-zk/internal/merkle"
+)
+
+// in-circuit counterpart of merkle.Hash, both must hash field elements the same way
+func newCircuitHasher(api frontend.API, h merkle.Hash) (hash.FieldHasher, error) {
+	switch h.Normalize() {
+	case merkle.HashMiMC:
+		m, err := mimc.NewMiMC(api)
+		if err != nil {
+			return nil, err
+		}
+		return &m, nil
+	case merkle.HashPoseidon2:
+		// gnark has no bn254 defaults in-circuit yet, use the same
+		// parameters as gnark-crypto's native hasher (width 2, 6 full, 50 partial)
+		p := bnposeidon2.GetDefaultParameters()
+		f, err := poseidon2.NewPoseidon2FromParameters(api, p.Width, p.NbFullRounds, p.NbPartialRounds)
+		if err != nil {
+			return nil, err
+		}
+		return hash.NewMerkleDamgardHasher(api, f, 0), nil
+	}
+	return nil, fmt.Errorf("unknown hash %q", h)
+}
+
+// each hash has its own circuit and keys. MiMC keeps the original shot.* names.
+func keyBase(dir string, h merkle.Hash) string {
+	if h.Normalize() == merkle.HashMiMC {
+		return filepath.Join(dir, "shot")
+	}
+	return filepath.Join(dir, "shot_"+string(h))
+}
+
+func VKPath(dir string, h merkle.Hash) string { return keyBase(dir, h) + ".vk" }
+func PKPath(dir string, h merkle.Hash) string { return keyBase(dir, h) + ".pk" }
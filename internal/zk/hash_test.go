@@ -0,0 +1,76 @@
+package zk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	"battleship-zk/internal/merkle"
+)
+
+// hashCircuit asserts the circuit hasher of Hash maps In to Out
+type hashCircuit struct {
+	In   [2]frontend.Variable
+	Out  frontend.Variable `gnark:",public"`
+	Hash merkle.Hash       `gnark:"-"`
+	One  bool              `gnark:"-"` // hash In[0] alone, like a leaf
+}
+
+func (c *hashCircuit) Define(api frontend.API) error {
+	h, err := newCircuitHasher(api, c.Hash)
+	if err != nil {
+		return err
+	}
+	h.Reset()
+	if c.One {
+		h.Write(c.In[0])
+	} else {
+		h.Write(c.In[0], c.In[1])
+	}
+	api.AssertIsEqual(h.Sum(), c.Out)
+	return nil
+}
+
+// a native/circuit mismatch would only show up as shots that can't be proved
+func TestCircuitHasherMatchesNative(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	big1 := new(big.Int).Sub(field, big.NewInt(1))
+	inputs := [][2]*big.Int{
+		{big.NewInt(0), big.NewInt(0)},
+		{big.NewInt(1), big.NewInt(2)},
+		{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 200)},
+		{big1, big1},
+	}
+	for _, h := range testHashes {
+		t.Run(string(h), func(t *testing.T) {
+			for _, in := range inputs {
+				native := map[bool]*big.Int{
+					false: h.Node(in[0], in[1]),
+					true:  h.Leaf(uint8(in[0].Uint64() & 1)),
+				}
+				for _, one := range []bool{false, true} {
+					a := in
+					if one {
+						a[0] = big.NewInt(int64(in[0].Uint64() & 1))
+					}
+					c := &hashCircuit{Hash: h, One: one}
+					w := &hashCircuit{In: [2]frontend.Variable{a[0], a[1]}, Out: native[one], Hash: h, One: one}
+					if err := test.IsSolved(c, w, field); err != nil {
+						t.Errorf("%v (one=%v): circuit hash differs from merkle.Hash: %v", a, one, err)
+					}
+					w.Out = new(big.Int).Add(native[one], big.NewInt(1))
+					if test.IsSolved(c, w, field) == nil {
+						t.Errorf("%v (one=%v): circuit accepts a wrong digest", a, one)
+					}
+				}
+			}
+			// the blinded leaf hashes (bit, nonce) like a node
+			if got, want := h.LeafBlinded(1, big.NewInt(77)), h.Node(big.NewInt(1), big.NewInt(77)); got.Cmp(want) != 0 {
+				t.Errorf("LeafBlinded(1, n) != Node(1, n)")
+			}
+		})
+	}
+}
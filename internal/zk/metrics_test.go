@@ -0,0 +1,90 @@
+package zk
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/metrics"
+)
+
+func metricValue(t *testing.T, m prometheus.Metric) float64 {
+	t.Helper()
+	var pb dto.Metric
+	if err := m.Write(&pb); err != nil {
+		t.Fatal(err)
+	}
+	if h := pb.GetHistogram(); h != nil {
+		return float64(h.GetSampleCount())
+	}
+	return pb.GetCounter().GetValue()
+}
+
+// a proof gets the same result in ProofsVerified and VerifySeconds, and is
+// labelled with the normalized hash
+func TestVerifyShotMetrics(t *testing.T) {
+	dir := t.TempDir()
+	h := merkle.HashMiMC
+	if err := EnsureShotKeys(dir, h); err != nil {
+		t.Fatal(err)
+	}
+	f := newFixture(t, h, 4)
+	hit, _ := f.cells()
+	path, bits, err := f.tree.Path(hit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proved := metrics.ProveSeconds.WithLabelValues(string(h)).(prometheus.Metric)
+	before := metricValue(t, proved)
+	// the zero Hash is mimc
+	proof, pub, err := ProveShot(context.Background(), dir, "", f.bit(hit), f.nonces[hit], hit, path, bits, f.tree.Root(), f.salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metricValue(t, proved) - before; got != 1 {
+		t.Errorf("prove_seconds{hash=%q} grew by %v, want 1", h, got)
+	}
+	root := h.Node(f.salt, f.tree.Root())
+
+	counters := func() map[string]float64 {
+		m := map[string]float64{}
+		for _, r := range []string{"hit", "miss", "invalid"} {
+			m["verified "+r] = metricValue(t, metrics.ProofsVerified.WithLabelValues(r))
+		}
+		for _, r := range []string{"valid", "invalid"} {
+			m["seconds "+r] = metricValue(t, metrics.VerifySeconds.WithLabelValues(string(h), r).(prometheus.Metric))
+		}
+		return m
+	}
+	tests := []struct {
+		name string
+		edit func(p *ShotPublic)
+		ok   bool
+		want map[string]float64
+	}{
+		{"honest", func(*ShotPublic) {}, true, map[string]float64{"verified hit": 1, "seconds valid": 1}},
+		{"hit flipped", func(p *ShotPublic) { p.Hit = 0 }, false, map[string]float64{"verified invalid": 1, "seconds invalid": 1}},
+		{"hit out of range", func(p *ShotPublic) { p.Hit = 2 }, false, map[string]float64{"verified invalid": 1}},
+		{"root mismatch", func(p *ShotPublic) { p.Root = big.NewInt(5) }, false, map[string]float64{"verified invalid": 1}},
+	}
+	for _, tc := range tests {
+		p := pub
+		tc.edit(&p)
+		b := counters()
+		ok, err := VerifyShot(VKPath(dir, h), proof, p, root)
+		if ok != tc.ok || (err == nil) != tc.ok {
+			t.Errorf("%s: VerifyShot = %v, %v", tc.name, ok, err)
+		}
+		a := counters()
+		for k := range a {
+			if got := a[k] - b[k]; got != tc.want[k] {
+				t.Errorf("%s: %s grew by %v, want %v", tc.name, k, got, tc.want[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package zk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bn254 "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// ProofPoints is a Groth16 proof as affine coordinates. G2 coordinates are
+// (A0, A1) pairs, i.e. A0 + A1*u.
+type ProofPoints struct {
+	A [2]*big.Int
+	B [2][2]*big.Int
+	C [2]*big.Int
+}
+
+func readBN254Proof(proofBin []byte) (*groth16bn254.Proof, error) {
+	var pr groth16bn254.Proof
+	if _, err := pr.ReadFrom(bytes.NewReader(proofBin)); err != nil {
+		return nil, err
+	}
+	if len(pr.Commitments) > 0 {
+		return nil, errors.New("proofs with commitments are not supported")
+	}
+	return &pr, nil
+}
+
+// PointsFromProof decodes gnark proof bytes (as returned by ProveShot)
+func PointsFromProof(proofBin []byte) (*ProofPoints, error) {
+	pr, err := readBN254Proof(proofBin)
+	if err != nil {
+		return nil, err
+	}
+	bi := func(e fp.Element) *big.Int { return e.BigInt(new(big.Int)) }
+	return &ProofPoints{
+		A: [2]*big.Int{bi(pr.Ar.X), bi(pr.Ar.Y)},
+		B: [2][2]*big.Int{
+			{bi(pr.Bs.X.A0), bi(pr.Bs.X.A1)},
+			{bi(pr.Bs.Y.A0), bi(pr.Bs.Y.A1)},
+		},
+		C: [2]*big.Int{bi(pr.Krs.X), bi(pr.Krs.Y)},
+	}, nil
+}
+
+// ProofFromPoints is the strict inverse of PointsFromProof: coordinates must
+// be reduced and every point on the curve and in the right subgroup.
+func ProofFromPoints(p *ProofPoints) ([]byte, error) {
+	var pr groth16bn254.Proof
+	set := func(dst *fp.Element, v *big.Int, name string) error {
+		if v == nil || v.Sign() < 0 || v.Cmp(fp.Modulus()) >= 0 {
+			return fmt.Errorf("proof %s is not a reduced base field element", name)
+		}
+		dst.SetBigInt(v)
+		return nil
+	}
+	for _, c := range []struct {
+		dst  *fp.Element
+		v    *big.Int
+		name string
+	}{
+		{&pr.Ar.X, p.A[0], "a.x"}, {&pr.Ar.Y, p.A[1], "a.y"},
+		{&pr.Bs.X.A0, p.B[0][0], "b.x.a0"}, {&pr.Bs.X.A1, p.B[0][1], "b.x.a1"},
+		{&pr.Bs.Y.A0, p.B[1][0], "b.y.a0"}, {&pr.Bs.Y.A1, p.B[1][1], "b.y.a1"},
+		{&pr.Krs.X, p.C[0], "c.x"}, {&pr.Krs.Y, p.C[1], "c.y"},
+	} {
+		if err := set(c.dst, c.v, c.name); err != nil {
+			return nil, err
+		}
+	}
+	if !g1Valid(&pr.Ar) || !g1Valid(&pr.Krs) {
+		return nil, errors.New("proof point a or c is not a valid G1 point")
+	}
+	if !pr.Bs.IsOnCurve() || !pr.Bs.IsInSubGroup() {
+		return nil, errors.New("proof point b is not a valid G2 point")
+	}
+	var buf bytes.Buffer
+	if _, err := pr.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func g1Valid(p *bn254.G1Affine) bool { return p.IsOnCurve() && p.IsInSubGroup() }
+
+// CheckProofBytes makes sure compressed proof bytes decode to valid points
+func CheckProofBytes(proofBin []byte) error {
+	pr, err := readBN254Proof(proofBin)
+	if err != nil {
+		return err
+	}
+	if !g1Valid(&pr.Ar) || !g1Valid(&pr.Krs) || !pr.Bs.IsOnCurve() || !pr.Bs.IsInSubGroup() {
+		return errors.New("proof has invalid curve points")
+	}
+	return nil
+}
@@ -2,16 +2,22 @@ package zk
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"log/slog"
 	"math/big"
 	"os"
+	"time"
 
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 
+	"battleship-zk/internal/logging"
 	"battleship-zk/internal/merkle"
+	"battleship-zk/internal/metrics"
 )
 
 type ShotPublic struct {
@@ -19,29 +25,52 @@ type ShotPublic struct {
 	Hit  uint8    `json:"hit"`
 	Row  uint8    `json:"row"`
 	Col  uint8    `json:"col"`
+	Hash string   `json:"hash,omitempty"` // merkle hash of the commitment, empty is mimc
 }
 
-func EnsureShotKeys(dir string) error {
+// CircuitVersion is bumped whenever ShotCircuit changes, keys made for
+// another version are regenerated by EnsureShotKeys
+const CircuitVersion = "shot-v3-range"
+
+// CompileShot builds the constraint system of ShotCircuit for the given hash
+func CompileShot(h merkle.Hash) (constraint.ConstraintSystem, error) {
+	circuit := ShotCircuit{Hash: h.Normalize()}
+	t0 := time.Now()
+	cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Since(metrics.CompileSeconds.WithLabelValues(string(circuit.Hash)), t0)
+	metrics.Constraints.WithLabelValues(string(circuit.Hash)).Set(float64(cs.GetNbConstraints()))
+	return cs, nil
+}
+
+func EnsureShotKeys(dir string, h merkle.Hash) error {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return err
 	}
-	vkPath := dir + "/shot.vk"
-	pkPath := dir + "/shot.pk"
+	vkPath := VKPath(dir, h)
+	pkPath := PKPath(dir, h)
+	versionPath := keyBase(dir, h) + ".version"
 
-	if vk, pk, err := readKeys(vkPath, pkPath); err == nil && vk != nil && pk != nil {
-		return nil
+	if v, err := os.ReadFile(versionPath); err == nil && string(bytes.TrimSpace(v)) == CircuitVersion {
+		if vk, pk, err := readKeys(vkPath, pkPath); err == nil && vk != nil && pk != nil {
+			return nil
+		}
 	}
 
-	var circuit ShotCircuit
-	cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	slog.Info("generating shot circuit keys", "dir", dir, "hash", h.Normalize(), "version", CircuitVersion)
+	cs, err := CompileShot(h)
 	if err != nil {
 		return err
 	}
 
+	t0 := time.Now()
 	pk, vk, err := groth16.Setup(cs)
 	if err != nil {
 		return err
 	}
+	slog.Info("shot circuit keys ready", "dir", dir, "hash", h.Normalize(), "constraints", cs.GetNbConstraints(), "setup_ms", time.Since(t0).Milliseconds())
 
 	if err := writeVK(vkPath, vk); err != nil {
 		return err
@@ -49,15 +78,21 @@ func EnsureShotKeys(dir string) error {
 	if err := writePK(pkPath, pk); err != nil {
 		return err
 	}
-	return nil
+	return os.WriteFile(versionPath, []byte(CircuitVersion+"\n"), 0o644)
 }
 
-func ProveShot(keysDir string, bit uint8, idx int, path []*big.Int, dir []uint8, root *big.Int, salt *big.Int) ([]byte, ShotPublic, error) {
+// ShotAssignment builds the full witness and public part for opening cell idx.
+// root is the unsalted tree root.
+func ShotAssignment(h merkle.Hash, bit uint8, nonce *big.Int, idx int, path []*big.Int, dir []uint8, root *big.Int, salt *big.Int) (ShotCircuit, ShotPublic, error) {
 	if len(path) != MerkleDepth || len(dir) != MerkleDepth {
-		return nil, ShotPublic{}, errors.New("bad path length")
+		return ShotCircuit{}, ShotPublic{}, errors.New("bad path length")
+	}
+	if nonce == nil {
+		return ShotCircuit{}, ShotPublic{}, errors.New("missing leaf nonce")
 	}
 
-	saltedRoot := merkle.HashNodeMiMC(salt, root)
+	h = h.Normalize()
+	saltedRoot := h.Node(salt, root)
 
 	row := uint8(idx / 10)
 	col := uint8(idx % 10)
@@ -67,41 +102,63 @@ func ProveShot(keysDir string, bit uint8, idx int, path []*big.Int, dir []uint8,
 		Hit:  bit,
 		Row:  row,
 		Col:  col,
+		Hash: string(h),
 	}
 
 	assign := ShotCircuit{
-		Bit:  bit,
-		Salt: salt,
+		Bit:   bit,
+		Nonce: nonce,
+		Salt:  salt,
 		Root: saltedRoot,
 		Hit:  bit,
 		Row:  row,
 		Col:  col,
+		Hash: h,
 	}
 
-	assign.Bit = bit
 	for i := 0; i < MerkleDepth; i++ {
 		assign.Path[i] = path[i]
 		assign.Dir[i] = dir[i]
 	}
+	return assign, pub, nil
+}
 
-	var circuit ShotCircuit
-	cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+// ProveShot proves the opening of cell idx. ctx is checked between the
+// compile, key load and prove steps; groth16.Prove itself has no
+// cancellation hook so a started prove runs to the end.
+func ProveShot(ctx context.Context, keysDir string, h merkle.Hash, bit uint8, nonce *big.Int, idx int, path []*big.Int, dir []uint8, root *big.Int, salt *big.Int) ([]byte, ShotPublic, error) {
+	assign, pub, err := ShotAssignment(h, bit, nonce, idx, path, dir, root, salt)
 	if err != nil {
 		return nil, ShotPublic{}, err
 	}
-	pk, err := readPK(keysDir + "/shot.pk")
+	h = assign.Hash
+
+	cs, err := CompileShot(h)
 	if err != nil {
 		return nil, ShotPublic{}, err
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, ShotPublic{}, err
+	}
+	pk, err := readPK(PKPath(keysDir, h))
+	if err != nil {
+		return nil, ShotPublic{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, ShotPublic{}, err
+	}
 
 	fullWit, err := frontend.NewWitness(&assign, ecc.BN254.ScalarField())
 	if err != nil {
 		return nil, ShotPublic{}, err
 	}
+	t0 := time.Now()
 	proof, err := groth16.Prove(cs, pk, fullWit)
 	if err != nil {
 		return nil, ShotPublic{}, err
 	}
+	metrics.Since(metrics.ProveSeconds.WithLabelValues(string(h.Normalize())), t0)
+	logging.From(ctx).Debug("groth16 prove", "hash", h, "constraints", cs.GetNbConstraints(), "duration_ms", time.Since(t0).Milliseconds())
 
 	var buf bytes.Buffer
 	if _, err := proof.WriteTo(&buf); err != nil {
@@ -110,13 +167,28 @@ func ProveShot(keysDir string, bit uint8, idx int, path []*big.Int, dir []uint8,
 	return buf.Bytes(), pub, nil
 }
 
-func VerifyShot(vkPath string, proofBin []byte, pub ShotPublic, root *big.Int) (bool, error) {
+// VerifyShot checks a shot proof against root. Every call is counted in
+// ProofsVerified, and timed in VerifySeconds once it reaches groth16.
+func VerifyShot(vkPath string, proofBin []byte, pub ShotPublic, root *big.Int) (ok bool, err error) {
+	defer func() {
+		result := "invalid"
+		if err == nil {
+			result = metrics.HitMiss(pub.Hit)
+		}
+		metrics.ProofsVerified.WithLabelValues(result).Inc()
+	}()
 	if pub.Root == nil {
 		return false, errors.New("proof payload missing public root")
 	}
 	if pub.Root.Cmp(root) != 0 {
 		return false, errors.New("root mismatch: proof root != --root")
 	}
+	if _, err := merkle.ParseHash(pub.Hash); err != nil {
+		return false, err
+	}
+	if pub.Hit > 1 {
+		return false, errors.New("invalid hit public output")
+	}
 
 	pubAssign := ShotCircuit{
 		Root: root,
@@ -139,7 +211,15 @@ func VerifyShot(vkPath string, proofBin []byte, pub ShotPublic, root *big.Int) (
 		return false, err
 	}
 
-	if err := groth16.Verify(pr, vk, pubWit); err != nil {
+	t0 := time.Now()
+	err = groth16.Verify(pr, vk, pubWit)
+	result := "valid"
+	if err != nil {
+		result = "invalid"
+	}
+	metrics.Since(metrics.VerifySeconds.WithLabelValues(string(merkle.Hash(pub.Hash).Normalize()), result), t0)
+	slog.Debug("groth16 verify", "hash", merkle.Hash(pub.Hash).Normalize(), "result", result, "duration_ms", time.Since(t0).Milliseconds())
+	if err != nil {
 		return false, err
 	}
 	return true, nil
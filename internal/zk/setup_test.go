@@ -0,0 +1,224 @@
+package zk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark/logger"
+
+	"battleship-zk/internal/game"
+	"battleship-zk/internal/merkle"
+)
+
+var testHashes = []merkle.Hash{merkle.HashMiMC, merkle.HashPoseidon2}
+
+func TestMain(m *testing.M) {
+	// rejected witnesses make gnark log every unsatisfied constraint
+	logger.Disable()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// fixture is a committed board: blinded leaves, tree and salt, the way
+// codec.Secret builds them
+type fixture struct {
+	h      merkle.Hash
+	board  game.Board
+	nonces []*big.Int
+	tree   *merkle.Tree
+	salt   *big.Int
+}
+
+func newFixture(tb testing.TB, h merkle.Hash, seed uint64) *fixture {
+	tb.Helper()
+	rng := game.NewSeededRand(seed)
+	b, err := game.GenerateBoard(rng, game.StrategyUniform)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	f := &fixture{h: h, board: b, nonces: make([]*big.Int, 100), salt: new(big.Int).SetUint64(rng.Uint64())}
+	for i := range f.nonces {
+		f.nonces[i] = new(big.Int).SetUint64(rng.Uint64())
+	}
+	if f.tree, err = merkle.BuildTree(h, b.Flatten(), f.nonces, 128); err != nil {
+		tb.Fatal(err)
+	}
+	return f
+}
+
+func (f *fixture) bit(idx int) uint8 { return f.board.Cells[idx/10][idx%10] }
+
+// cells is the first ship cell and the first water cell
+func (f *fixture) cells() (hit, miss int) {
+	hit, miss = -1, -1
+	for i, v := range f.board.Flatten() {
+		if v == 1 && hit < 0 {
+			hit = i
+		}
+		if v == 0 && miss < 0 {
+			miss = i
+		}
+	}
+	return hit, miss
+}
+
+func (f *fixture) assignment(tb testing.TB, idx int) (ShotCircuit, ShotPublic) {
+	tb.Helper()
+	path, dir, err := f.tree.Path(idx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	a, pub, err := ShotAssignment(f.h, f.bit(idx), f.nonces[idx], idx, path, dir, f.tree.Root(), f.salt)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return a, pub
+}
+
+func (f *fixture) prove(tb testing.TB, keysDir string, idx int) ([]byte, ShotPublic) {
+	tb.Helper()
+	path, dir, err := f.tree.Path(idx)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	proof, pub, err := ProveShot(context.Background(), keysDir, f.h, f.bit(idx), f.nonces[idx], idx, path, dir, f.tree.Root(), f.salt)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return proof, pub
+}
+
+// BenchmarkProveShot is one answered shot: compile, key load and prove
+func BenchmarkProveShot(b *testing.B) {
+	dir := b.TempDir()
+	for _, h := range testHashes {
+		b.Run(string(h), func(b *testing.B) {
+			if err := EnsureShotKeys(dir, h); err != nil {
+				b.Fatal(err)
+			}
+			f := newFixture(b, h, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f.prove(b, dir, (i*37)%100)
+			}
+		})
+	}
+}
+
+func TestEnsureShotKeys(t *testing.T) {
+	dir := t.TempDir()
+	h := merkle.HashMiMC
+	if err := EnsureShotKeys(dir, h); err != nil {
+		t.Fatal(err)
+	}
+	vk, err := os.ReadFile(VKPath(dir, h))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// current keys are kept
+	if err := EnsureShotKeys(dir, h); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(VKPath(dir, h)); !bytes.Equal(again, vk) {
+		t.Fatal("keys of the current circuit version were regenerated")
+	}
+
+	// keys of another circuit version are replaced
+	if err := os.WriteFile(keyBase(dir, h)+".version", []byte("shot-v0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureShotKeys(dir, h); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(VKPath(dir, h)); bytes.Equal(again, vk) {
+		t.Fatal("keys of an old circuit version were kept")
+	}
+	if v, _ := os.ReadFile(keyBase(dir, h) + ".version"); string(bytes.TrimSpace(v)) != CircuitVersion {
+		t.Fatalf("version file says %q", v)
+	}
+}
+
+// ProveShot -> VerifyShot end to end, then every public field tampered
+func TestProveVerifyShot(t *testing.T) {
+	dir := t.TempDir()
+	for _, h := range testHashes {
+		t.Run(string(h), func(t *testing.T) {
+			if err := EnsureShotKeys(dir, h); err != nil {
+				t.Fatal(err)
+			}
+			f := newFixture(t, h, 3)
+			hit, _ := f.cells()
+			proof, pub := f.prove(t, dir, hit)
+			root := h.Node(f.salt, f.tree.Root())
+			vk := VKPath(dir, h)
+
+			if pub.Hit != 1 || int(pub.Row)*10+int(pub.Col) != hit || pub.Hash != string(h) {
+				t.Fatalf("public part %+v for cell %d", pub, hit)
+			}
+			if ok, err := VerifyShot(vk, proof, pub, root); !ok || err != nil {
+				t.Fatalf("honest proof rejected: %v", err)
+			}
+
+			other := testHashes[0]
+			if h == other {
+				other = testHashes[1]
+			}
+			tests := []struct {
+				name  string
+				edit  func(p *ShotPublic)
+				root  *big.Int
+				proof []byte
+				vk    string
+			}{
+				{name: "hit flipped", edit: func(p *ShotPublic) { p.Hit ^= 1 }},
+				{name: "row changed", edit: func(p *ShotPublic) { p.Row = (p.Row + 1) % 10 }},
+				{name: "col changed", edit: func(p *ShotPublic) { p.Col = (p.Col + 1) % 10 }},
+				{name: "row out of range", edit: func(p *ShotPublic) { p.Row = 10 }},
+				{name: "root changed", edit: func(p *ShotPublic) { p.Root = new(big.Int).Add(p.Root, big.NewInt(1)) }},
+				{name: "root of another commitment", edit: func(p *ShotPublic) { p.Root = big.NewInt(5) }, root: big.NewInt(5)},
+				{name: "missing root", edit: func(p *ShotPublic) { p.Root = nil }},
+				{name: "unknown hash", edit: func(p *ShotPublic) { p.Hash = "sha256" }},
+				{name: "proof bytes corrupted", proof: corrupt(proof)},
+				{name: "proof truncated", proof: proof[:len(proof)/2]},
+				{name: "vk of the other hash", vk: VKPath(dir, other)},
+			}
+			if err := EnsureShotKeys(dir, other); err != nil {
+				t.Fatal(err)
+			}
+			for _, tc := range tests {
+				t.Run(tc.name, func(t *testing.T) {
+					p := pub
+					p.Root = new(big.Int).Set(pub.Root)
+					if tc.edit != nil {
+						tc.edit(&p)
+					}
+					r, pr, v := root, proof, vk
+					if tc.root != nil {
+						r = tc.root
+					}
+					if tc.proof != nil {
+						pr = tc.proof
+					}
+					if tc.vk != "" {
+						v = tc.vk
+					}
+					if ok, err := VerifyShot(v, pr, p, r); ok || err == nil {
+						t.Fatalf("tampered payload verified")
+					}
+				})
+			}
+		})
+	}
+}
+
+func corrupt(b []byte) []byte {
+	c := bytes.Clone(b)
+	c[len(c)/2] ^= 0xff
+	return c
+}
@@ -2,17 +2,22 @@ package zk
 
 import (
 	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/std/hash/mimc"
 	"github.com/consensys/gnark/std/math/bits"
+
+	"battleship-zk/internal/merkle"
 )
 
 const MerkleDepth = 7 // 128 leaves
 
 type ShotCircuit struct {
-	Bit  frontend.Variable              `gnark:",secret"`
-	Path [MerkleDepth]frontend.Variable `gnark:",secret"`
-	Dir  [MerkleDepth]frontend.Variable `gnark:",secret"`
-	Salt frontend.Variable `gnark:",secret"`
+	Bit   frontend.Variable              `gnark:",secret"`
+	Nonce frontend.Variable              `gnark:",secret"` // per-cell leaf blinding
+	Path  [MerkleDepth]frontend.Variable `gnark:",secret"`
+	Dir   [MerkleDepth]frontend.Variable `gnark:",secret"`
+	Salt  frontend.Variable              `gnark:",secret"`
+
+	// picked at compile time, not part of the witness
+	Hash merkle.Hash `gnark:"-"`
 
 	Root frontend.Variable `gnark:",public"`
 	Hit  frontend.Variable `gnark:",public"`
@@ -25,12 +30,13 @@ func (c *ShotCircuit) Define(api frontend.API) error {
 	api.AssertIsBoolean(c.Hit)
 	api.AssertIsEqual(c.Hit, c.Bit)
 
-	h, err := mimc.NewMiMC(api)
+	h, err := newCircuitHasher(api, c.Hash)
 	if err != nil {
 		return err
 	}
+	// leaf = H(bit, nonce), same as merkle.Hash.LeafBlinded
 	h.Reset()
-	h.Write(c.Bit)
+	h.Write(c.Bit, c.Nonce)
 	curr := h.Sum()
 
 	// walk Merkle path
@@ -47,7 +53,7 @@ func (c *ShotCircuit) Define(api frontend.API) error {
 
 	treeRoot := curr
 
-	hSalt, err := mimc.NewMiMC(api)
+	hSalt, err := newCircuitHasher(api, c.Hash)
 	if err != nil {
 		return err
 	}
@@ -57,14 +63,29 @@ func (c *ShotCircuit) Define(api frontend.API) error {
 
 	api.AssertIsEqual(salted, c.Root)
 
+	// row and col must be real board coordinates, otherwise e.g. (0,13) and
+	// (1,3) open the same leaf and (12,0) lands in the padding
+	assertDigit(api, c.Row)
+	assertDigit(api, c.Col)
+
 	// make sure its the correct index
 	idx := api.Add(api.Mul(c.Row, 10), c.Col) // idx = row*10 + col
+	api.AssertIsLessOrEqual(idx, 99)
 	idxBits := bits.ToBinary(api, idx, bits.WithNbDigits(MerkleDepth))
 
 	for i := 0; i < MerkleDepth; i++ {
 		api.AssertIsBoolean(idxBits[i])
 		api.AssertIsEqual(c.Dir[i], idxBits[i])
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
+
+// v in 0..9, as v*(v-1)*...*(v-9) == 0, 10 constraints
+func assertDigit(api frontend.API, v frontend.Variable) {
+	prod := v
+	for k := 1; k <= 9; k++ {
+		prod = api.Mul(prod, api.Sub(v, k))
+	}
+	api.AssertIsEqual(prod, 0)
+}
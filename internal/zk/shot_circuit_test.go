@@ -0,0 +1,215 @@
+package zk
+
+import (
+	"math/big"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	"battleship-zk/internal/merkle"
+)
+
+// opening is what a defender hands the circuit for one board cell
+type opening struct {
+	bit   uint8
+	nonce *big.Int
+	idx   int
+	path  []*big.Int
+	dir   []uint8
+	salt  *big.Int
+}
+
+func (f *fixture) opening(t *testing.T, idx int) opening {
+	t.Helper()
+	path, dir, err := f.tree.Path(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opening{bit: f.bit(idx), nonce: new(big.Int).Set(f.nonces[idx]), idx: idx, path: path, dir: dir, salt: new(big.Int).Set(f.salt)}
+}
+
+// circuit is the witness of o against the real public root
+func (o opening) circuit(f *fixture) *ShotCircuit {
+	a := &ShotCircuit{
+		Bit: o.bit, Nonce: o.nonce, Salt: o.salt, Hash: f.h,
+		Root: f.h.Node(f.salt, f.tree.Root()),
+		Hit:  o.bit, Row: o.idx / 10, Col: o.idx % 10,
+	}
+	for i := range a.Path {
+		a.Path[i], a.Dir[i] = o.path[i], o.dir[i]
+	}
+	return a
+}
+
+// merkle.Hash.VerifySaltedPath and the circuit must agree on every board
+// cell opening: a path accepted natively proves, a rejected one doesn't
+func TestNativePathCheckMatchesCircuit(t *testing.T) {
+	one := big.NewInt(1)
+	tests := []struct {
+		name   string
+		edit   func(o *opening)
+		native bool
+	}{
+		{"honest", func(o *opening) {}, true},
+		{"other bit", func(o *opening) { o.bit ^= 1 }, false},
+		{"other nonce", func(o *opening) { o.nonce.Add(o.nonce, one) }, false},
+		{"sibling changed", func(o *opening) { o.path[2] = new(big.Int).Add(o.path[2], one) }, false},
+		{"top sibling changed", func(o *opening) { o.path[MerkleDepth-1] = big.NewInt(0) }, false},
+		{"dir flipped", func(o *opening) { o.dir[0] ^= 1 }, false},
+		{"dir not boolean", func(o *opening) { o.dir[3] = 2 }, false},
+		{"salt changed", func(o *opening) { o.salt.Add(o.salt, one) }, false},
+		{"path of the next cell", func(o *opening) { o.idx = (o.idx + 1) % 100 }, false},
+	}
+	for _, h := range testHashes {
+		f := newFixture(t, h, 7)
+		saltedRoot := h.Node(f.salt, f.tree.Root())
+		hit, miss := f.cells()
+		for _, idx := range []int{hit, miss, 0, 99} {
+			for _, tc := range tests {
+				o := f.opening(t, idx)
+				o.path, o.dir = slices.Clone(o.path), slices.Clone(o.dir)
+				tc.edit(&o)
+
+				nativeErr := h.VerifySaltedPath(h.LeafBlinded(o.bit, o.nonce), o.idx, o.path, o.dir, o.salt, saltedRoot)
+				circuitErr := test.IsSolved(&ShotCircuit{Hash: h}, o.circuit(f), ecc.BN254.ScalarField())
+				if (nativeErr == nil) != tc.native {
+					t.Errorf("%s cell %d %s: native err = %v, want accepted %v", h, idx, tc.name, nativeErr, tc.native)
+				}
+				if (nativeErr == nil) != (circuitErr == nil) {
+					t.Errorf("%s cell %d %s: native err = %v, circuit err = %v", h, idx, tc.name, nativeErr, circuitErr)
+				}
+			}
+		}
+	}
+}
+
+func flipBit(v frontend.Variable) frontend.Variable { return v.(uint8) ^ 1 }
+
+func TestShotCircuit(t *testing.T) {
+	assert := test.NewAssert(t)
+	opts := []test.TestingOption{test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16), test.NoFuzzing(), test.NoSerializationChecks()}
+
+	tests := []struct {
+		name string
+		edit func(a *ShotCircuit, idx int)
+	}{
+		{"flipped bit and hit", func(a *ShotCircuit, _ int) { a.Bit, a.Hit = flipBit(a.Bit), flipBit(a.Hit) }},
+		{"hit is not bit", func(a *ShotCircuit, _ int) { a.Hit = flipBit(a.Hit) }},
+		{"bit not boolean", func(a *ShotCircuit, _ int) { a.Bit, a.Hit = 2, 2 }},
+		{"lowest direction bit flipped", func(a *ShotCircuit, _ int) { a.Dir[0] = flipBit(a.Dir[0]) }},
+		{"top direction bit flipped", func(a *ShotCircuit, _ int) { a.Dir[MerkleDepth-1] = flipBit(a.Dir[MerkleDepth-1]) }},
+		{"next cell's direction bits", func(a *ShotCircuit, idx int) {
+			for i := range a.Dir {
+				a.Dir[i] = uint8(((idx + 1) >> i) & 1)
+			}
+		}},
+		{"wrong sibling", func(a *ShotCircuit, _ int) { a.Path[3] = big.NewInt(12345) }},
+		{"bad salt", func(a *ShotCircuit, _ int) { a.Salt = big.NewInt(7) }},
+		{"bad nonce", func(a *ShotCircuit, _ int) { a.Nonce = big.NewInt(7) }},
+		{"public root of another commitment", func(a *ShotCircuit, _ int) { a.Root = big.NewInt(1) }},
+		{"other cell's coordinates", func(a *ShotCircuit, idx int) { a.Col = (idx%10 + 1) % 10 }},
+		{"row out of range", func(a *ShotCircuit, _ int) { a.Row = 10 }},
+		{"col out of range", func(a *ShotCircuit, _ int) { a.Col = 10 }},
+		{"negative row", func(a *ShotCircuit, _ int) { a.Row = -1 }},
+	}
+	for _, h := range testHashes {
+		f := newFixture(t, h, 42)
+		hit, miss := f.cells()
+		for _, idx := range []int{hit, miss} {
+			valid, _ := f.assignment(t, idx)
+			assert.Run(func(assert *test.Assert) {
+				assert.NoError(test.IsSolved(&ShotCircuit{Hash: h}, &valid, ecc.BN254.ScalarField()))
+				assert.CheckCircuit(&ShotCircuit{Hash: h}, append(opts, test.WithValidAssignment(&valid))...)
+			}, string(h), "valid", strconv.Itoa(idx))
+
+			for _, tc := range tests {
+				bad, _ := f.assignment(t, idx)
+				tc.edit(&bad, idx)
+				assert.Run(func(assert *test.Assert) {
+					assert.ProverFailed(&ShotCircuit{Hash: h}, &bad, opts...)
+				}, string(h), tc.name, strconv.Itoa(idx))
+			}
+		}
+	}
+}
+
+// Row and Col are only bound through idx = row*10+col, so without the range
+// checks other coordinates could open the same leaf, or a leaf past the board
+func TestShotCircuitCoordinates(t *testing.T) {
+	assert := test.NewAssert(t)
+	opts := []test.TestingOption{test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16), test.NoFuzzing(), test.NoSerializationChecks()}
+	field := ecc.BN254.ScalarField()
+	neg := func(v int64) *big.Int { return new(big.Int).Add(field, big.NewInt(v)) }
+
+	for _, h := range testHashes {
+		f := newFixture(t, h, 9)
+
+		// same leaf index as the real cell, only the coordinates differ
+		aliases := []struct {
+			name     string
+			idx      int
+			row, col any
+		}{
+			{"(0,13) for (1,3)", 13, 0, 13},
+			{"(4,-5) for (3,5)", 35, 4, neg(-5)},
+			{"(-1,20) for (1,0)", 10, neg(-1), 20},
+			{"(0,99) for (9,9)", 99, 0, 99},
+		}
+		for _, tc := range aliases {
+			a, _ := f.assignment(t, tc.idx)
+			a.Row, a.Col = tc.row, tc.col
+			assert.Run(func(assert *test.Assert) {
+				assert.ProverFailed(&ShotCircuit{Hash: h}, &a, opts...)
+			}, string(h), "aliased", tc.name)
+		}
+
+		// a tree whose padding leaves are blinded like cells, so leaves past
+		// the board open like any other and only the range checks object
+		leaves := make([]*big.Int, 128)
+		nonces := make([]*big.Int, 128)
+		for i := range leaves {
+			nonces[i] = big.NewInt(int64(1000 + i))
+			leaves[i] = h.LeafBlinded(0, nonces[i])
+		}
+		tree, err := merkle.BuildFromLeaves(leaves, 128, leaves[0], h.Node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		open := func(idx int, row, col any) *ShotCircuit {
+			path, dir, err := tree.Path(idx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, _, err := ShotAssignment(h, 0, nonces[idx], idx%100, path, dir, tree.Root(), f.salt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.Row, a.Col = row, col
+			return &a
+		}
+		if err := test.IsSolved(&ShotCircuit{Hash: h}, open(55, 5, 5), field); err != nil {
+			t.Fatalf("%s: board cell of the blinded-padding tree rejected: %v", h, err)
+		}
+		padding := []struct {
+			name     string
+			idx      int
+			row, col any
+		}{
+			{"(10,0)", 100, 10, 0},
+			{"(12,7)", 127, 12, 7},
+			{"(9,10)", 100, 9, 10},
+			{"(0,100)", 100, 0, 100},
+		}
+		for _, tc := range padding {
+			a := open(tc.idx, tc.row, tc.col)
+			assert.Run(func(assert *test.Assert) {
+				assert.ProverFailed(&ShotCircuit{Hash: h}, a, opts...)
+			}, string(h), "padding", tc.name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package zk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// ExportSolidityVerifier writes gnark's Solidity verifier for the key at vkPath.
+// The contract exposes verifyProof(uint256[8] proof, uint256[4] input) and
+// reverts on invalid proofs, input is (root, hit, row, col).
+func ExportSolidityVerifier(vkPath string, w io.Writer) error {
+	vk, err := readVK(vkPath)
+	if err != nil {
+		return err
+	}
+	return vk.ExportSolidity(w)
+}
+
+// SolidityCalldata converts a proof payload into the arguments of verifyProof
+func SolidityCalldata(proofBin []byte, pub ShotPublic) (proof [8]*big.Int, input [4]*big.Int, err error) {
+	if pub.Root == nil {
+		return proof, input, errors.New("proof payload missing public root")
+	}
+	pr := groth16.NewProof(ecc.BN254)
+	if _, err := pr.ReadFrom(bytes.NewReader(proofBin)); err != nil {
+		return proof, input, err
+	}
+	bnProof, ok := pr.(*groth16bn254.Proof)
+	if !ok {
+		return proof, input, errors.New("not a bn254 proof")
+	}
+	if len(bnProof.Commitments) > 0 {
+		return proof, input, errors.New("proofs with commitments are not supported")
+	}
+	raw := bnProof.MarshalSolidity()
+	if len(raw) != 8*32 {
+		return proof, input, errors.New("unexpected solidity proof size")
+	}
+	for i := range proof {
+		proof[i] = new(big.Int).SetBytes(raw[i*32 : (i+1)*32])
+	}
+	input = [4]*big.Int{
+		new(big.Int).Set(pub.Root),
+		big.NewInt(int64(pub.Hit)),
+		big.NewInt(int64(pub.Row)),
+		big.NewInt(int64(pub.Col)),
+	}
+	return proof, input, nil
+}
@@ -0,0 +1,236 @@
+package zk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+
+	"battleship-zk/internal/merkle"
+)
+
+// compiled is one contract out of solc --combined-json
+type compiled struct {
+	ABI abi.ABI
+	Bin []byte
+}
+
+// needSolc skips the test without solc in PATH, CI installs it
+func needSolc(t *testing.T) string {
+	t.Helper()
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Skip("solc not found in PATH")
+	}
+	return solc
+}
+
+// solcCompile compiles the .sol files and returns their contracts by name
+func solcCompile(t *testing.T, solc string, files ...string) map[string]compiled {
+	t.Helper()
+	args := append([]string{"--combined-json", "abi,bin", "--optimize"}, files...)
+	out, err := exec.Command(solc, args...).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			t.Fatalf("solc: %v\n%s", err, ee.Stderr)
+		}
+		t.Fatal(err)
+	}
+	var res struct {
+		Contracts map[string]struct {
+			ABI json.RawMessage `json:"abi"`
+			Bin string          `json:"bin"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	contracts := map[string]compiled{}
+	for name, c := range res.Contracts {
+		raw := c.ABI
+		// solc before 0.8.10 quotes the abi as a string
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			raw = json.RawMessage(s)
+		}
+		a, err := abi.JSON(strings.NewReader(string(raw)))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		contracts[name[strings.LastIndex(name, ":")+1:]] = compiled{ABI: a, Bin: common.FromHex(c.Bin)}
+	}
+	return contracts
+}
+
+type chain struct {
+	t    *testing.T
+	sim  *simulated.Backend
+	keys []*ecdsa.PrivateKey
+}
+
+func newChain(t *testing.T, accounts int) *chain {
+	c := &chain{t: t}
+	alloc := types.GenesisAlloc{}
+	for i := 0; i < accounts; i++ {
+		k, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.keys = append(c.keys, k)
+		alloc[crypto.PubkeyToAddress(k.PublicKey)] = types.Account{Balance: new(big.Int).Lsh(big.NewInt(1), 100)}
+	}
+	c.sim = simulated.NewBackend(alloc)
+	t.Cleanup(func() { c.sim.Close() })
+	return c
+}
+
+func (c *chain) opts(i int) *bind.TransactOpts {
+	o, err := bind.NewKeyedTransactorWithChainID(c.keys[i], big.NewInt(1337))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return o
+}
+
+func (c *chain) deploy(from int, ct compiled, params ...any) (common.Address, *bind.BoundContract) {
+	c.t.Helper()
+	addr, _, bound, err := bind.DeployContract(c.opts(from), ct.ABI, ct.Bin, c.sim.Client(), params...)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.sim.Commit()
+	return addr, bound
+}
+
+// send mines a transaction and returns its error, a revert fails gas estimation
+func (c *chain) send(from int, bc *bind.BoundContract, value *big.Int, method string, params ...any) error {
+	c.t.Helper()
+	o := c.opts(from)
+	o.Value = value
+	tx, err := bc.Transact(o, method, params...)
+	if err != nil {
+		return err
+	}
+	c.sim.Commit()
+	r, err := c.sim.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		return err
+	}
+	if r.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%s reverted", method)
+	}
+	return nil
+}
+
+func (c *chain) call(bc *bind.BoundContract, method string, params ...any) ([]any, error) {
+	var out []any
+	err := bc.Call(&bind.CallOpts{}, &out, method, params...)
+	return out, err
+}
+
+// The exported verifier accepts SolidityCalldata (what export-calldata
+// writes) and rejects it tampered, on its own and behind the settlement
+func TestSolidityVerifierOnChain(t *testing.T) {
+	solc := needSolc(t)
+	dir := t.TempDir()
+	h := merkle.HashMiMC
+	if err := EnsureShotKeys(dir, h); err != nil {
+		t.Fatal(err)
+	}
+	sol := filepath.Join(dir, "Verifier.sol")
+	f, err := os.Create(sol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportSolidityVerifier(VKPath(dir, h), f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	contracts := solcCompile(t, solc, sol, filepath.Join("..", "..", "contracts", "BattleshipSettlement.sol"))
+
+	fx := newFixture(t, h, 5)
+	hit, _ := fx.cells()
+	proofBin, pub := fx.prove(t, dir, hit)
+	proof, input, err := SolidityCalldata(proofBin, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newChain(t, 2)
+	vAddr, verifier := c.deploy(0, contracts["Verifier"])
+
+	if _, err := c.call(verifier, "verifyProof", proof, input); err != nil {
+		t.Fatalf("verifyProof rejected the exported calldata: %v", err)
+	}
+	tampered := []struct {
+		name string
+		edit func(p *[8]*big.Int, in *[4]*big.Int)
+	}{
+		{"hit flipped", func(_ *[8]*big.Int, in *[4]*big.Int) { in[1] = big.NewInt(0) }},
+		{"row changed", func(_ *[8]*big.Int, in *[4]*big.Int) { in[2] = new(big.Int).Add(in[2], big.NewInt(1)) }},
+		{"col changed", func(_ *[8]*big.Int, in *[4]*big.Int) { in[3] = big.NewInt(int64(pub.Col+1) % 10) }},
+		{"root changed", func(_ *[8]*big.Int, in *[4]*big.Int) { in[0] = new(big.Int).Add(in[0], big.NewInt(1)) }},
+		{"proof A changed", func(p *[8]*big.Int, _ *[4]*big.Int) { p[0] = new(big.Int).Add(p[0], big.NewInt(1)) }},
+		{"proof C swapped", func(p *[8]*big.Int, _ *[4]*big.Int) { p[6], p[7] = p[7], p[6] }},
+	}
+	for _, tc := range tampered {
+		p, in := proof, input
+		for i := range p {
+			p[i] = new(big.Int).Set(proof[i])
+		}
+		for i := range in {
+			in[i] = new(big.Int).Set(input[i])
+		}
+		tc.edit(&p, &in)
+		if _, err := c.call(verifier, "verifyProof", p, in); err == nil {
+			t.Errorf("verifyProof accepted calldata with %s", tc.name)
+		}
+	}
+
+	// player 0 attacks, player 1 defends with the board proved above
+	stake := big.NewInt(1e15)
+	_, game := c.deploy(0, contracts["BattleshipSettlement"], stake, big.NewInt(3600))
+	if err := c.send(0, game, stake, "join", vAddr, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.send(1, game, stake, "join", vAddr, pub.Root); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.send(0, game, nil, "fire", pub.Row, pub.Col); err != nil {
+		t.Fatal(err)
+	}
+	bad := proof
+	bad[0] = new(big.Int).Add(proof[0], big.NewInt(1))
+	if c.send(1, game, nil, "respond", bad, true) == nil {
+		t.Fatal("respond accepted a tampered proof")
+	}
+	if c.send(1, game, nil, "respond", proof, false) == nil {
+		t.Fatal("respond accepted a hit answered as a miss")
+	}
+	if err := c.send(1, game, nil, "respond", proof, true); err != nil {
+		t.Fatalf("respond rejected the honest proof: %v", err)
+	}
+	out, err := c.call(game, "pending")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[0].(bool) {
+		t.Fatal("shot still pending after the answer")
+	}
+	if out, err = c.call(game, "turn"); err != nil || out[0].(uint8) != 1 {
+		t.Fatalf("turn = %v (%v), want the defender's", out, err)
+	}
+}
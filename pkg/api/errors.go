@@ -0,0 +1,60 @@
+package api
+
+// ErrorCode is the machine readable reason in an Error body
+type ErrorCode string
+
+const (
+	CodeBadRequest       ErrorCode = "bad_request" // malformed body or argument
+	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
+	CodeInvalidBoard     ErrorCode = "invalid_board" // commit of a board that breaks the rules
+	CodeNotCommitted     ErrorCode = "not_committed" // shot before any board was committed
+	CodeNotYourTurn      ErrorCode = "not_your_turn"
+	CodeCellTargeted     ErrorCode = "cell_already_targeted"
+	CodeGameOver         ErrorCode = "game_over"
+	CodeBadProof         ErrorCode = "bad_proof"        // payload malformed or proof rejected
+	CodeUnauthenticated  ErrorCode = "unauthenticated"  // shoot without a verified peer cert under mTLS
+	CodePeerUnavailable  ErrorCode = "peer_unavailable" // fire could not reach the opponent's server
+	CodeForbiddenOrigin  ErrorCode = "forbidden_origin" // browser request from a page the CORS policy doesn't allow
+	CodeTooLarge         ErrorCode = "request_too_large"
+	CodeRateLimited      ErrorCode = "rate_limited" // too many requests from this client, see Retry-After
+	CodeProverBusy       ErrorCode = "prover_busy"  // proving queue full, see Retry-After
+	CodeUnavailable      ErrorCode = "unavailable"  // shot abandoned, the server is shutting down or the caller left
+	CodeInternal         ErrorCode = "internal"
+)
+
+// ErrorCodes lists every code the server can send
+var ErrorCodes = []ErrorCode{
+	CodeBadRequest, CodeMethodNotAllowed, CodeInvalidBoard, CodeNotCommitted,
+	CodeNotYourTurn, CodeCellTargeted, CodeGameOver, CodeBadProof,
+	CodeUnauthenticated, CodePeerUnavailable, CodeForbiddenOrigin, CodeTooLarge,
+	CodeRateLimited, CodeProverBusy, CodeUnavailable, CodeInternal,
+}
+
+// ErrorStatus is the HTTP status sent with each code
+var ErrorStatus = map[ErrorCode]int{
+	CodeBadRequest:       400,
+	CodeInvalidBoard:     400,
+	CodeNotCommitted:     400,
+	CodeBadProof:         400,
+	CodeUnauthenticated:  401,
+	CodeForbiddenOrigin:  403,
+	CodeMethodNotAllowed: 405,
+	CodeNotYourTurn:      409,
+	CodeCellTargeted:     409,
+	CodeGameOver:         409,
+	CodeTooLarge:         413,
+	CodeRateLimited:      429,
+	CodeInternal:         500,
+	CodePeerUnavailable:  502,
+	CodeProverBusy:       503,
+	CodeUnavailable:      503,
+}
+
+// Error is the body of every non-2xx response. Message stays under "error"
+// so older clients reading a plain string keep working.
+type Error struct {
+	Code    ErrorCode  `json:"code"`
+	Message string     `json:"error"`
+	Turn    *TurnState `json:"turn,omitempty"` // set with not_your_turn and cell_already_targeted
+	Game    *GameState `json:"game,omitempty"` // set with game_over
+}
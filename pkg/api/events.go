@@ -0,0 +1,20 @@
+package api
+
+type EventType string
+
+const (
+	EventStatus   EventType = "status"    // full Status, always the first message
+	EventTurn     EventType = "turn"      // Turn changed
+	EventDefense  EventType = "defense"   // the opponent fired at us, Shot and Game
+	EventVerified EventType = "verified"  // our shot was verified, Shot.Bit is the hit, and Game
+	EventGameOver EventType = "game_over" // Game
+)
+
+// Event is one message on the /v1/ws push channel
+type Event struct {
+	Type   EventType  `json:"type"`
+	Status *Status    `json:"status,omitempty"`
+	Turn   *TurnState `json:"turn,omitempty"`
+	Shot   *ShotEvent `json:"shot,omitempty"`
+	Game   *GameState `json:"game,omitempty"`
+}
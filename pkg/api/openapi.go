@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type route struct {
+	path, method, summary string
+	req, resp             any    // nil for no body
+	status                int    // of a success, 200 if 0
+	media                 string // of the response, "application/json" if empty
+}
+
+// every endpoint the server routes, OpenAPI is built from this table
+var routes = []route{
+	{"/v1/init", "post", "Random valid board, not committed yet", InitRequest{}, Board{}, 0, ""},
+	{"/v1/commit", "post", "Commit a board and keep its secret on the server", CommitRequest{}, CommitResponse{}, 0, ""},
+	{"/v1/shoot", "post", "Fire at this server's board, answered with a proof", ShootRequest{}, ShootResponse{}, 0, ""},
+	{"/v1/verify", "post", "Verify the opponent's answer to our shot", VerifyRequest{}, VerifyResponse{}, 0, ""},
+	{"/v1/fire", "post", "Shoot at the registered peer through this server and verify the answer", ShootRequest{}, VerifyResponse{}, 0, ""},
+	{"/v1/status", "get", "Turn, game and peer state", nil, Status{}, 0, ""},
+	{"/v1/peer", "put", "Register the opponent's server", Peer{}, Status{}, 0, ""},
+	{"/v1/ws", "get", "WebSocket of game events, each text frame is one Event", nil, Event{}, 101, ""},
+	{"/v1/openapi.json", "get", "This document", nil, map[string]any{}, 0, ""},
+	{"/metrics", "get", "Prometheus metrics", nil, "", 0, "text/plain"},
+}
+
+// OpenAPI returns the OpenAPI 3.0 document of the API. The schemas are
+// reflected from the structs in this package, so the spec follows the types.
+func OpenAPI() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]any{}
+
+	// every route passes the same middleware, so any code can come back
+	errRef := schemaOf(reflect.TypeOf(Error{}), schemas)
+	byStatus := map[int][]string{}
+	for _, c := range ErrorCodes {
+		st := ErrorStatus[c]
+		byStatus[st] = append(byStatus[st], string(c))
+	}
+
+	for _, r := range routes {
+		status, media := r.status, r.media
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if media == "" {
+			media = "application/json"
+		}
+		responses := map[string]any{
+			strconv.Itoa(status): map[string]any{
+				"description": http.StatusText(status),
+				"content": map[string]any{media: map[string]any{
+					"schema": schemaOf(reflect.TypeOf(r.resp), schemas),
+				}},
+			},
+		}
+		for st, codes := range byStatus {
+			responses[strconv.Itoa(st)] = map[string]any{
+				"description": strings.Join(codes, ", "),
+				"content":     map[string]any{"application/json": map[string]any{"schema": errRef}},
+			}
+		}
+		op := map[string]any{
+			"summary":     r.summary,
+			"operationId": strings.TrimPrefix(strings.TrimPrefix(r.path, "/v1/"), "/"),
+			"responses":   responses,
+		}
+		if r.req != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{"application/json": map[string]any{
+					"schema": schemaOf(reflect.TypeOf(r.req), schemas),
+				}},
+			}
+		}
+		paths[r.path] = map[string]any{r.method: op}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Battleship-ZK player API",
+			"version": "1",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	errorCodeType  = reflect.TypeOf(ErrorCode(""))
+)
+
+// schemaOf describes t the way encoding/json writes it, structs are added to
+// schemas and referenced by name
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t {
+	case rawMessageType:
+		return map[string]any{"type": "object", "description": "versioned proof payload, see README"}
+	case errorCodeType:
+		enum := make([]string, len(ErrorCodes))
+		for i, c := range ErrorCodes {
+			enum[i] = string(c)
+		}
+		return map[string]any{"type": "string", "enum": enum}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]any{"allOf": []any{schemaOf(t.Elem(), schemas)}, "nullable": true}
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // placeholder against recursion
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Array:
+		return map[string]any{
+			"type":     "array",
+			"items":    schemaOf(t.Elem(), schemas),
+			"minItems": t.Len(),
+			"maxItems": t.Len(),
+		}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Uint8:
+		return map[string]any{"type": "integer", "minimum": 0, "maximum": 255}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0}
+	}
+	panic("api: no OpenAPI schema for " + t.String())
+}
+
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = schemaOf(f.Type, schemas)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
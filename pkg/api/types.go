@@ -0,0 +1,123 @@
+// Package api holds the JSON bodies of the /v1 HTTP API. internal/server
+// writes them and pkg/client reads them.
+package api
+
+import "encoding/json"
+
+// ShipCells is the number of ship cells on a board, the game is over when
+// either side has taken that many hits
+const ShipCells = 17
+
+// Board is a 10x10 grid, 1 marks a ship cell. Same JSON as game.Board.
+type Board struct{ Cells [10][10]uint8 }
+
+type InitRequest struct {
+	Strategy string  `json:"strategy,omitempty"` // uniform, edge-avoid, spread or anti-hunt
+	Seed     *uint64 `json:"seed,omitempty"`     // nil means crypto/rand
+}
+
+type CommitRequest struct {
+	Board Board  `json:"board"`
+	Hash  string `json:"hash,omitempty"` // mimc (default) or poseidon2
+}
+
+type CommitResponse struct {
+	RootHex string `json:"rootHex"`
+}
+
+type ShootRequest struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// ShootResponse is the defender's answer to a shot. Payload is the versioned
+// proof JSON and is passed on to Verify unchanged.
+type ShootResponse struct {
+	Payload json.RawMessage `json:"payload"`
+	Bit     uint8           `json:"bit"`
+	RootHex string          `json:"rootHex"`
+	VKB64   string          `json:"vkB64"`
+}
+
+type VerifyRequest struct {
+	RootHex string          `json:"rootHex"`
+	Payload json.RawMessage `json:"payload"`
+	VKB64   string          `json:"vkB64,omitempty"`
+}
+
+type VerifyResponse struct {
+	Valid bool  `json:"valid"`
+	Hit   uint8 `json:"hit"`
+}
+
+// Peer is both the PUT /v1/peer body and the peer in Status
+type Peer struct {
+	BaseURL string `json:"baseUrl"`
+	RootHex string `json:"rootHex,omitempty"`
+	VKB64   string `json:"vkB64,omitempty"`
+}
+
+type TurnState struct {
+	MyTurn  string `json:"myTurn"` // "me", "opponent" or empty until decided
+	Ready   bool   `json:"ready"`
+	Decided bool   `json:"decided"`
+}
+
+type GameState struct {
+	HitsTaken int    `json:"hitsTaken"`
+	HitsDealt int    `json:"hitsDealt"`
+	Over      bool   `json:"over"`
+	Winner    string `json:"winner"` // "me" or "opponent" once over
+}
+
+// ShotEvent is the last shot fired at us, N counts them and is 0 before the first
+type ShotEvent struct {
+	Row int   `json:"row"`
+	Col int   `json:"col"`
+	Bit uint8 `json:"bit"`
+	N   int   `json:"n"`
+	At  int64 `json:"at"` // unix millis
+}
+
+// PeerCheck is one liveness probe of the peer's /v1/status
+type PeerCheck struct {
+	At        int64  `json:"at"` // unix millis
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PeerHealth is what the background monitor knows about the peer
+type PeerHealth struct {
+	Online    bool        `json:"online"`
+	StartedAt int64       `json:"startedAt"`
+	LastSeen  int64       `json:"lastSeen,omitempty"` // unix millis of the last good probe
+	Failures  int         `json:"failures"`           // consecutive failed probes
+	History   []PeerCheck `json:"history"`            // latest last
+}
+
+// Load is how busy the server is. Shots wait for one of ProveWorkers, at
+// most ProveQueue of them, the rest are turned away with prover_busy.
+type Load struct {
+	ProveWorkers  int    `json:"proveWorkers"`
+	ProveBusy     int    `json:"proveBusy"`
+	ProveQueued   int    `json:"proveQueued"`
+	ProveQueueMax int    `json:"proveQueueMax"`
+	ProveRejected uint64 `json:"proveRejected"` // since start
+	RateLimited   uint64 `json:"rateLimited"`   // requests answered 429 since start
+}
+
+type Status struct {
+	StartedAt   int64       `json:"startedAt"`
+	MyID        string      `json:"myId"`
+	OppID       string      `json:"oppId"`
+	MyRootHex   string      `json:"myRootHex"`
+	OppRootHex  string      `json:"oppRootHex"`
+	Peer        *Peer       `json:"peer"`
+	PeerHealth  *PeerHealth `json:"peerHealth,omitempty"`
+	Load        *Load       `json:"load,omitempty"`
+	Turn        TurnState   `json:"turn"`
+	Game        GameState   `json:"game"`
+	VKB64       string      `json:"vkB64"`
+	DefenseLast ShotEvent   `json:"defenseLast"`
+}
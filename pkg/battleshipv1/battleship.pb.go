@@ -0,0 +1,1249 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: battleship/v1/battleship.proto
+
+package battleshipv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 10x10 board, cells[row*10+col] is 1 for a ship cell
+type Board struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cells         []uint32               `protobuf:"varint,1,rep,packed,name=cells,proto3" json:"cells,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Board) Reset() {
+	*x = Board{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Board) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Board) ProtoMessage() {}
+
+func (x *Board) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Board.ProtoReflect.Descriptor instead.
+func (*Board) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Board) GetCells() []uint32 {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+type InitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// uniform (default), edge-avoid, spread or anti-hunt
+	Strategy string `protobuf:"bytes,1,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	// unset means crypto/rand
+	Seed          *uint64 `protobuf:"varint,2,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitRequest) Reset() {
+	*x = InitRequest{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitRequest) ProtoMessage() {}
+
+func (x *InitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitRequest.ProtoReflect.Descriptor instead.
+func (*InitRequest) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *InitRequest) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *InitRequest) GetSeed() uint64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+type CommitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Board *Board                 `protobuf:"bytes,1,opt,name=board,proto3" json:"board,omitempty"`
+	// mimc (default) or poseidon2
+	Hash          string `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitRequest) Reset() {
+	*x = CommitRequest{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitRequest) ProtoMessage() {}
+
+func (x *CommitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitRequest.ProtoReflect.Descriptor instead.
+func (*CommitRequest) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CommitRequest) GetBoard() *Board {
+	if x != nil {
+		return x.Board
+	}
+	return nil
+}
+
+func (x *CommitRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type CommitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootHex       string                 `protobuf:"bytes,1,opt,name=root_hex,json=rootHex,proto3" json:"root_hex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitResponse) Reset() {
+	*x = CommitResponse{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitResponse) ProtoMessage() {}
+
+func (x *CommitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitResponse.ProtoReflect.Descriptor instead.
+func (*CommitResponse) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CommitResponse) GetRootHex() string {
+	if x != nil {
+		return x.RootHex
+	}
+	return ""
+}
+
+type ShootRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Row           uint32                 `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
+	Col           uint32                 `protobuf:"varint,2,opt,name=col,proto3" json:"col,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShootRequest) Reset() {
+	*x = ShootRequest{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShootRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShootRequest) ProtoMessage() {}
+
+func (x *ShootRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShootRequest.ProtoReflect.Descriptor instead.
+func (*ShootRequest) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ShootRequest) GetRow() uint32 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *ShootRequest) GetCol() uint32 {
+	if x != nil {
+		return x.Col
+	}
+	return 0
+}
+
+type ShotPublic struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// salted board root, 0x prefixed hex
+	Root          string `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Hit           uint32 `protobuf:"varint,2,opt,name=hit,proto3" json:"hit,omitempty"`
+	Row           uint32 `protobuf:"varint,3,opt,name=row,proto3" json:"row,omitempty"`
+	Col           uint32 `protobuf:"varint,4,opt,name=col,proto3" json:"col,omitempty"`
+	Hash          string `protobuf:"bytes,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShotPublic) Reset() {
+	*x = ShotPublic{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShotPublic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShotPublic) ProtoMessage() {}
+
+func (x *ShotPublic) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShotPublic.ProtoReflect.Descriptor instead.
+func (*ShotPublic) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ShotPublic) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *ShotPublic) GetHit() uint32 {
+	if x != nil {
+		return x.Hit
+	}
+	return 0
+}
+
+func (x *ShotPublic) GetRow() uint32 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *ShotPublic) GetCol() uint32 {
+	if x != nil {
+		return x.Col
+	}
+	return 0
+}
+
+func (x *ShotPublic) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type ShotProof struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// groth16 proof in gnark's binary encoding
+	Proof         []byte      `protobuf:"bytes,1,opt,name=proof,proto3" json:"proof,omitempty"`
+	Public        *ShotPublic `protobuf:"bytes,2,opt,name=public,proto3" json:"public,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShotProof) Reset() {
+	*x = ShotProof{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShotProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShotProof) ProtoMessage() {}
+
+func (x *ShotProof) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShotProof.ProtoReflect.Descriptor instead.
+func (*ShotProof) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShotProof) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *ShotProof) GetPublic() *ShotPublic {
+	if x != nil {
+		return x.Public
+	}
+	return nil
+}
+
+type ShootResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Payload *ShotProof             `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Bit     uint32                 `protobuf:"varint,2,opt,name=bit,proto3" json:"bit,omitempty"`
+	RootHex string                 `protobuf:"bytes,3,opt,name=root_hex,json=rootHex,proto3" json:"root_hex,omitempty"`
+	// verifying key of the defender's circuit
+	Vk            []byte `protobuf:"bytes,4,opt,name=vk,proto3" json:"vk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShootResponse) Reset() {
+	*x = ShootResponse{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShootResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShootResponse) ProtoMessage() {}
+
+func (x *ShootResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShootResponse.ProtoReflect.Descriptor instead.
+func (*ShootResponse) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ShootResponse) GetPayload() *ShotProof {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ShootResponse) GetBit() uint32 {
+	if x != nil {
+		return x.Bit
+	}
+	return 0
+}
+
+func (x *ShootResponse) GetRootHex() string {
+	if x != nil {
+		return x.RootHex
+	}
+	return ""
+}
+
+func (x *ShootResponse) GetVk() []byte {
+	if x != nil {
+		return x.Vk
+	}
+	return nil
+}
+
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootHex       string                 `protobuf:"bytes,1,opt,name=root_hex,json=rootHex,proto3" json:"root_hex,omitempty"`
+	Payload       *ShotProof             `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	Vk            []byte                 `protobuf:"bytes,3,opt,name=vk,proto3" json:"vk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *VerifyRequest) GetRootHex() string {
+	if x != nil {
+		return x.RootHex
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetPayload() *ShotProof {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetVk() []byte {
+	if x != nil {
+		return x.Vk
+	}
+	return nil
+}
+
+type VerifyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Hit           uint32                 `protobuf:"varint,2,opt,name=hit,proto3" json:"hit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *VerifyResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetHit() uint32 {
+	if x != nil {
+		return x.Hit
+	}
+	return 0
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{10}
+}
+
+type Peer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseUrl       string                 `protobuf:"bytes,1,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	RootHex       string                 `protobuf:"bytes,2,opt,name=root_hex,json=rootHex,proto3" json:"root_hex,omitempty"`
+	Vk            []byte                 `protobuf:"bytes,3,opt,name=vk,proto3" json:"vk,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peer) Reset() {
+	*x = Peer{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peer) ProtoMessage() {}
+
+func (x *Peer) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peer.ProtoReflect.Descriptor instead.
+func (*Peer) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Peer) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *Peer) GetRootHex() string {
+	if x != nil {
+		return x.RootHex
+	}
+	return ""
+}
+
+func (x *Peer) GetVk() []byte {
+	if x != nil {
+		return x.Vk
+	}
+	return nil
+}
+
+type TurnState struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "me", "opponent" or empty until decided
+	MyTurn        string `protobuf:"bytes,1,opt,name=my_turn,json=myTurn,proto3" json:"my_turn,omitempty"`
+	Ready         bool   `protobuf:"varint,2,opt,name=ready,proto3" json:"ready,omitempty"`
+	Decided       bool   `protobuf:"varint,3,opt,name=decided,proto3" json:"decided,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnState) Reset() {
+	*x = TurnState{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnState) ProtoMessage() {}
+
+func (x *TurnState) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnState.ProtoReflect.Descriptor instead.
+func (*TurnState) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TurnState) GetMyTurn() string {
+	if x != nil {
+		return x.MyTurn
+	}
+	return ""
+}
+
+func (x *TurnState) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *TurnState) GetDecided() bool {
+	if x != nil {
+		return x.Decided
+	}
+	return false
+}
+
+type GameState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HitsTaken     int32                  `protobuf:"varint,1,opt,name=hits_taken,json=hitsTaken,proto3" json:"hits_taken,omitempty"`
+	HitsDealt     int32                  `protobuf:"varint,2,opt,name=hits_dealt,json=hitsDealt,proto3" json:"hits_dealt,omitempty"`
+	Over          bool                   `protobuf:"varint,3,opt,name=over,proto3" json:"over,omitempty"`
+	Winner        string                 `protobuf:"bytes,4,opt,name=winner,proto3" json:"winner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GameState) Reset() {
+	*x = GameState{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameState) ProtoMessage() {}
+
+func (x *GameState) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameState.ProtoReflect.Descriptor instead.
+func (*GameState) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GameState) GetHitsTaken() int32 {
+	if x != nil {
+		return x.HitsTaken
+	}
+	return 0
+}
+
+func (x *GameState) GetHitsDealt() int32 {
+	if x != nil {
+		return x.HitsDealt
+	}
+	return 0
+}
+
+func (x *GameState) GetOver() bool {
+	if x != nil {
+		return x.Over
+	}
+	return false
+}
+
+func (x *GameState) GetWinner() string {
+	if x != nil {
+		return x.Winner
+	}
+	return ""
+}
+
+// last shot the opponent fired at us, n counts them
+type ShotEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Row           int32                  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
+	Col           int32                  `protobuf:"varint,2,opt,name=col,proto3" json:"col,omitempty"`
+	Bit           uint32                 `protobuf:"varint,3,opt,name=bit,proto3" json:"bit,omitempty"`
+	N             int32                  `protobuf:"varint,4,opt,name=n,proto3" json:"n,omitempty"`
+	At            int64                  `protobuf:"varint,5,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShotEvent) Reset() {
+	*x = ShotEvent{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShotEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShotEvent) ProtoMessage() {}
+
+func (x *ShotEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShotEvent.ProtoReflect.Descriptor instead.
+func (*ShotEvent) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ShotEvent) GetRow() int32 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *ShotEvent) GetCol() int32 {
+	if x != nil {
+		return x.Col
+	}
+	return 0
+}
+
+func (x *ShotEvent) GetBit() uint32 {
+	if x != nil {
+		return x.Bit
+	}
+	return 0
+}
+
+func (x *ShotEvent) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *ShotEvent) GetAt() int64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartedAt     int64                  `protobuf:"varint,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	MyId          string                 `protobuf:"bytes,2,opt,name=my_id,json=myId,proto3" json:"my_id,omitempty"`
+	OppId         string                 `protobuf:"bytes,3,opt,name=opp_id,json=oppId,proto3" json:"opp_id,omitempty"`
+	MyRootHex     string                 `protobuf:"bytes,4,opt,name=my_root_hex,json=myRootHex,proto3" json:"my_root_hex,omitempty"`
+	OppRootHex    string                 `protobuf:"bytes,5,opt,name=opp_root_hex,json=oppRootHex,proto3" json:"opp_root_hex,omitempty"`
+	Peer          *Peer                  `protobuf:"bytes,6,opt,name=peer,proto3" json:"peer,omitempty"`
+	Turn          *TurnState             `protobuf:"bytes,7,opt,name=turn,proto3" json:"turn,omitempty"`
+	Game          *GameState             `protobuf:"bytes,8,opt,name=game,proto3" json:"game,omitempty"`
+	Vk            []byte                 `protobuf:"bytes,9,opt,name=vk,proto3" json:"vk,omitempty"`
+	DefenseLast   *ShotEvent             `protobuf:"bytes,10,opt,name=defense_last,json=defenseLast,proto3" json:"defense_last,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StatusResponse) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetMyId() string {
+	if x != nil {
+		return x.MyId
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetOppId() string {
+	if x != nil {
+		return x.OppId
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetMyRootHex() string {
+	if x != nil {
+		return x.MyRootHex
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetOppRootHex() string {
+	if x != nil {
+		return x.OppRootHex
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetPeer() *Peer {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetTurn() *TurnState {
+	if x != nil {
+		return x.Turn
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetGame() *GameState {
+	if x != nil {
+		return x.Game
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetVk() []byte {
+	if x != nil {
+		return x.Vk
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetDefenseLast() *ShotEvent {
+	if x != nil {
+		return x.DefenseLast
+	}
+	return nil
+}
+
+type SetPeerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// HTTP base url of the opponent server
+	BaseUrl string `protobuf:"bytes,1,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	RootHex string `protobuf:"bytes,2,opt,name=root_hex,json=rootHex,proto3" json:"root_hex,omitempty"`
+	Vk      []byte `protobuf:"bytes,3,opt,name=vk,proto3" json:"vk,omitempty"`
+	// our own HTTP base url as the peer reaches it, needed the first time
+	// since a gRPC call can't tell it like the HTTP Host header does
+	SelfUrl       string `protobuf:"bytes,4,opt,name=self_url,json=selfUrl,proto3" json:"self_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPeerRequest) Reset() {
+	*x = SetPeerRequest{}
+	mi := &file_battleship_v1_battleship_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPeerRequest) ProtoMessage() {}
+
+func (x *SetPeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_battleship_v1_battleship_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPeerRequest.ProtoReflect.Descriptor instead.
+func (*SetPeerRequest) Descriptor() ([]byte, []int) {
+	return file_battleship_v1_battleship_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetPeerRequest) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *SetPeerRequest) GetRootHex() string {
+	if x != nil {
+		return x.RootHex
+	}
+	return ""
+}
+
+func (x *SetPeerRequest) GetVk() []byte {
+	if x != nil {
+		return x.Vk
+	}
+	return nil
+}
+
+func (x *SetPeerRequest) GetSelfUrl() string {
+	if x != nil {
+		return x.SelfUrl
+	}
+	return ""
+}
+
+var File_battleship_v1_battleship_proto protoreflect.FileDescriptor
+
+const file_battleship_v1_battleship_proto_rawDesc = "" +
+	"\n" +
+	"\x1ebattleship/v1/battleship.proto\x12\rbattleship.v1\"\x1d\n" +
+	"\x05Board\x12\x14\n" +
+	"\x05cells\x18\x01 \x03(\rR\x05cells\"K\n" +
+	"\vInitRequest\x12\x1a\n" +
+	"\bstrategy\x18\x01 \x01(\tR\bstrategy\x12\x17\n" +
+	"\x04seed\x18\x02 \x01(\x04H\x00R\x04seed\x88\x01\x01B\a\n" +
+	"\x05_seed\"O\n" +
+	"\rCommitRequest\x12*\n" +
+	"\x05board\x18\x01 \x01(\v2\x14.battleship.v1.BoardR\x05board\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\"+\n" +
+	"\x0eCommitResponse\x12\x19\n" +
+	"\broot_hex\x18\x01 \x01(\tR\arootHex\"2\n" +
+	"\fShootRequest\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\rR\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\rR\x03col\"j\n" +
+	"\n" +
+	"ShotPublic\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\tR\x04root\x12\x10\n" +
+	"\x03hit\x18\x02 \x01(\rR\x03hit\x12\x10\n" +
+	"\x03row\x18\x03 \x01(\rR\x03row\x12\x10\n" +
+	"\x03col\x18\x04 \x01(\rR\x03col\x12\x12\n" +
+	"\x04hash\x18\x05 \x01(\tR\x04hash\"T\n" +
+	"\tShotProof\x12\x14\n" +
+	"\x05proof\x18\x01 \x01(\fR\x05proof\x121\n" +
+	"\x06public\x18\x02 \x01(\v2\x19.battleship.v1.ShotPublicR\x06public\"\x80\x01\n" +
+	"\rShootResponse\x122\n" +
+	"\apayload\x18\x01 \x01(\v2\x18.battleship.v1.ShotProofR\apayload\x12\x10\n" +
+	"\x03bit\x18\x02 \x01(\rR\x03bit\x12\x19\n" +
+	"\broot_hex\x18\x03 \x01(\tR\arootHex\x12\x0e\n" +
+	"\x02vk\x18\x04 \x01(\fR\x02vk\"n\n" +
+	"\rVerifyRequest\x12\x19\n" +
+	"\broot_hex\x18\x01 \x01(\tR\arootHex\x122\n" +
+	"\apayload\x18\x02 \x01(\v2\x18.battleship.v1.ShotProofR\apayload\x12\x0e\n" +
+	"\x02vk\x18\x03 \x01(\fR\x02vk\"8\n" +
+	"\x0eVerifyResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x10\n" +
+	"\x03hit\x18\x02 \x01(\rR\x03hit\"\x0f\n" +
+	"\rStatusRequest\"L\n" +
+	"\x04Peer\x12\x19\n" +
+	"\bbase_url\x18\x01 \x01(\tR\abaseUrl\x12\x19\n" +
+	"\broot_hex\x18\x02 \x01(\tR\arootHex\x12\x0e\n" +
+	"\x02vk\x18\x03 \x01(\fR\x02vk\"T\n" +
+	"\tTurnState\x12\x17\n" +
+	"\amy_turn\x18\x01 \x01(\tR\x06myTurn\x12\x14\n" +
+	"\x05ready\x18\x02 \x01(\bR\x05ready\x12\x18\n" +
+	"\adecided\x18\x03 \x01(\bR\adecided\"u\n" +
+	"\tGameState\x12\x1d\n" +
+	"\n" +
+	"hits_taken\x18\x01 \x01(\x05R\thitsTaken\x12\x1d\n" +
+	"\n" +
+	"hits_dealt\x18\x02 \x01(\x05R\thitsDealt\x12\x12\n" +
+	"\x04over\x18\x03 \x01(\bR\x04over\x12\x16\n" +
+	"\x06winner\x18\x04 \x01(\tR\x06winner\"_\n" +
+	"\tShotEvent\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x05R\x03row\x12\x10\n" +
+	"\x03col\x18\x02 \x01(\x05R\x03col\x12\x10\n" +
+	"\x03bit\x18\x03 \x01(\rR\x03bit\x12\f\n" +
+	"\x01n\x18\x04 \x01(\x05R\x01n\x12\x0e\n" +
+	"\x02at\x18\x05 \x01(\x03R\x02at\"\xef\x02\n" +
+	"\x0eStatusResponse\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x01 \x01(\x03R\tstartedAt\x12\x13\n" +
+	"\x05my_id\x18\x02 \x01(\tR\x04myId\x12\x15\n" +
+	"\x06opp_id\x18\x03 \x01(\tR\x05oppId\x12\x1e\n" +
+	"\vmy_root_hex\x18\x04 \x01(\tR\tmyRootHex\x12 \n" +
+	"\fopp_root_hex\x18\x05 \x01(\tR\n" +
+	"oppRootHex\x12'\n" +
+	"\x04peer\x18\x06 \x01(\v2\x13.battleship.v1.PeerR\x04peer\x12,\n" +
+	"\x04turn\x18\a \x01(\v2\x18.battleship.v1.TurnStateR\x04turn\x12,\n" +
+	"\x04game\x18\b \x01(\v2\x18.battleship.v1.GameStateR\x04game\x12\x0e\n" +
+	"\x02vk\x18\t \x01(\fR\x02vk\x12;\n" +
+	"\fdefense_last\x18\n" +
+	" \x01(\v2\x18.battleship.v1.ShotEventR\vdefenseLast\"q\n" +
+	"\x0eSetPeerRequest\x12\x19\n" +
+	"\bbase_url\x18\x01 \x01(\tR\abaseUrl\x12\x19\n" +
+	"\broot_hex\x18\x02 \x01(\tR\arootHex\x12\x0e\n" +
+	"\x02vk\x18\x03 \x01(\fR\x02vk\x12\x19\n" +
+	"\bself_url\x18\x04 \x01(\tR\aselfUrl2\xec\x03\n" +
+	"\n" +
+	"Battleship\x128\n" +
+	"\x04Init\x12\x1a.battleship.v1.InitRequest\x1a\x14.battleship.v1.Board\x12E\n" +
+	"\x06Commit\x12\x1c.battleship.v1.CommitRequest\x1a\x1d.battleship.v1.CommitResponse\x12B\n" +
+	"\x05Shoot\x12\x1b.battleship.v1.ShootRequest\x1a\x1c.battleship.v1.ShootResponse\x12E\n" +
+	"\x06Verify\x12\x1c.battleship.v1.VerifyRequest\x1a\x1d.battleship.v1.VerifyResponse\x12B\n" +
+	"\x04Fire\x12\x1b.battleship.v1.ShootRequest\x1a\x1d.battleship.v1.VerifyResponse\x12E\n" +
+	"\x06Status\x12\x1c.battleship.v1.StatusRequest\x1a\x1d.battleship.v1.StatusResponse\x12G\n" +
+	"\aSetPeer\x12\x1d.battleship.v1.SetPeerRequest\x1a\x1d.battleship.v1.StatusResponseB-Z+battleship-zk/pkg/battleshipv1;battleshipv1b\x06proto3"
+
+var (
+	file_battleship_v1_battleship_proto_rawDescOnce sync.Once
+	file_battleship_v1_battleship_proto_rawDescData []byte
+)
+
+func file_battleship_v1_battleship_proto_rawDescGZIP() []byte {
+	file_battleship_v1_battleship_proto_rawDescOnce.Do(func() {
+		file_battleship_v1_battleship_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_battleship_v1_battleship_proto_rawDesc), len(file_battleship_v1_battleship_proto_rawDesc)))
+	})
+	return file_battleship_v1_battleship_proto_rawDescData
+}
+
+var file_battleship_v1_battleship_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_battleship_v1_battleship_proto_goTypes = []any{
+	(*Board)(nil),          // 0: battleship.v1.Board
+	(*InitRequest)(nil),    // 1: battleship.v1.InitRequest
+	(*CommitRequest)(nil),  // 2: battleship.v1.CommitRequest
+	(*CommitResponse)(nil), // 3: battleship.v1.CommitResponse
+	(*ShootRequest)(nil),   // 4: battleship.v1.ShootRequest
+	(*ShotPublic)(nil),     // 5: battleship.v1.ShotPublic
+	(*ShotProof)(nil),      // 6: battleship.v1.ShotProof
+	(*ShootResponse)(nil),  // 7: battleship.v1.ShootResponse
+	(*VerifyRequest)(nil),  // 8: battleship.v1.VerifyRequest
+	(*VerifyResponse)(nil), // 9: battleship.v1.VerifyResponse
+	(*StatusRequest)(nil),  // 10: battleship.v1.StatusRequest
+	(*Peer)(nil),           // 11: battleship.v1.Peer
+	(*TurnState)(nil),      // 12: battleship.v1.TurnState
+	(*GameState)(nil),      // 13: battleship.v1.GameState
+	(*ShotEvent)(nil),      // 14: battleship.v1.ShotEvent
+	(*StatusResponse)(nil), // 15: battleship.v1.StatusResponse
+	(*SetPeerRequest)(nil), // 16: battleship.v1.SetPeerRequest
+}
+var file_battleship_v1_battleship_proto_depIdxs = []int32{
+	0,  // 0: battleship.v1.CommitRequest.board:type_name -> battleship.v1.Board
+	5,  // 1: battleship.v1.ShotProof.public:type_name -> battleship.v1.ShotPublic
+	6,  // 2: battleship.v1.ShootResponse.payload:type_name -> battleship.v1.ShotProof
+	6,  // 3: battleship.v1.VerifyRequest.payload:type_name -> battleship.v1.ShotProof
+	11, // 4: battleship.v1.StatusResponse.peer:type_name -> battleship.v1.Peer
+	12, // 5: battleship.v1.StatusResponse.turn:type_name -> battleship.v1.TurnState
+	13, // 6: battleship.v1.StatusResponse.game:type_name -> battleship.v1.GameState
+	14, // 7: battleship.v1.StatusResponse.defense_last:type_name -> battleship.v1.ShotEvent
+	1,  // 8: battleship.v1.Battleship.Init:input_type -> battleship.v1.InitRequest
+	2,  // 9: battleship.v1.Battleship.Commit:input_type -> battleship.v1.CommitRequest
+	4,  // 10: battleship.v1.Battleship.Shoot:input_type -> battleship.v1.ShootRequest
+	8,  // 11: battleship.v1.Battleship.Verify:input_type -> battleship.v1.VerifyRequest
+	4,  // 12: battleship.v1.Battleship.Fire:input_type -> battleship.v1.ShootRequest
+	10, // 13: battleship.v1.Battleship.Status:input_type -> battleship.v1.StatusRequest
+	16, // 14: battleship.v1.Battleship.SetPeer:input_type -> battleship.v1.SetPeerRequest
+	0,  // 15: battleship.v1.Battleship.Init:output_type -> battleship.v1.Board
+	3,  // 16: battleship.v1.Battleship.Commit:output_type -> battleship.v1.CommitResponse
+	7,  // 17: battleship.v1.Battleship.Shoot:output_type -> battleship.v1.ShootResponse
+	9,  // 18: battleship.v1.Battleship.Verify:output_type -> battleship.v1.VerifyResponse
+	9,  // 19: battleship.v1.Battleship.Fire:output_type -> battleship.v1.VerifyResponse
+	15, // 20: battleship.v1.Battleship.Status:output_type -> battleship.v1.StatusResponse
+	15, // 21: battleship.v1.Battleship.SetPeer:output_type -> battleship.v1.StatusResponse
+	15, // [15:22] is the sub-list for method output_type
+	8,  // [8:15] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_battleship_v1_battleship_proto_init() }
+func file_battleship_v1_battleship_proto_init() {
+	if File_battleship_v1_battleship_proto != nil {
+		return
+	}
+	file_battleship_v1_battleship_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_battleship_v1_battleship_proto_rawDesc), len(file_battleship_v1_battleship_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_battleship_v1_battleship_proto_goTypes,
+		DependencyIndexes: file_battleship_v1_battleship_proto_depIdxs,
+		MessageInfos:      file_battleship_v1_battleship_proto_msgTypes,
+	}.Build()
+	File_battleship_v1_battleship_proto = out.File
+	file_battleship_v1_battleship_proto_goTypes = nil
+	file_battleship_v1_battleship_proto_depIdxs = nil
+}
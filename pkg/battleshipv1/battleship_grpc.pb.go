@@ -0,0 +1,367 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: battleship/v1/battleship.proto
+
+package battleshipv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Battleship_Init_FullMethodName    = "/battleship.v1.Battleship/Init"
+	Battleship_Commit_FullMethodName  = "/battleship.v1.Battleship/Commit"
+	Battleship_Shoot_FullMethodName   = "/battleship.v1.Battleship/Shoot"
+	Battleship_Verify_FullMethodName  = "/battleship.v1.Battleship/Verify"
+	Battleship_Fire_FullMethodName    = "/battleship.v1.Battleship/Fire"
+	Battleship_Status_FullMethodName  = "/battleship.v1.Battleship/Status"
+	Battleship_SetPeer_FullMethodName = "/battleship.v1.Battleship/SetPeer"
+)
+
+// BattleshipClient is the client API for Battleship service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// mirror of the /v1/* HTTP JSON API, both are served from the same
+// internal/server state so a game can be driven over either
+type BattleshipClient interface {
+	// random valid board, not committed yet
+	Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*Board, error)
+	// commit a board, keeps the secret on the server and returns the salted root
+	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error)
+	// opponent fires at (row, col), answered with a proof of hit/miss
+	Shoot(ctx context.Context, in *ShootRequest, opts ...grpc.CallOption) (*ShootResponse, error)
+	// check the proof we got back for our own shot
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	// our own shot: this server asks the registered peer and verifies the answer
+	Fire(ctx context.Context, in *ShootRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	// register the opponent server, answered with the new status
+	SetPeer(ctx context.Context, in *SetPeerRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+}
+
+type battleshipClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBattleshipClient(cc grpc.ClientConnInterface) BattleshipClient {
+	return &battleshipClient{cc}
+}
+
+func (c *battleshipClient) Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*Board, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Board)
+	err := c.cc.Invoke(ctx, Battleship_Init_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *battleshipClient) Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitResponse)
+	err := c.cc.Invoke(ctx, Battleship_Commit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *battleshipClient) Shoot(ctx context.Context, in *ShootRequest, opts ...grpc.CallOption) (*ShootResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShootResponse)
+	err := c.cc.Invoke(ctx, Battleship_Shoot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *battleshipClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, Battleship_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *battleshipClient) Fire(ctx context.Context, in *ShootRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, Battleship_Fire_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *battleshipClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, Battleship_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *battleshipClient) SetPeer(ctx context.Context, in *SetPeerRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, Battleship_SetPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BattleshipServer is the server API for Battleship service.
+// All implementations must embed UnimplementedBattleshipServer
+// for forward compatibility.
+//
+// mirror of the /v1/* HTTP JSON API, both are served from the same
+// internal/server state so a game can be driven over either
+type BattleshipServer interface {
+	// random valid board, not committed yet
+	Init(context.Context, *InitRequest) (*Board, error)
+	// commit a board, keeps the secret on the server and returns the salted root
+	Commit(context.Context, *CommitRequest) (*CommitResponse, error)
+	// opponent fires at (row, col), answered with a proof of hit/miss
+	Shoot(context.Context, *ShootRequest) (*ShootResponse, error)
+	// check the proof we got back for our own shot
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	// our own shot: this server asks the registered peer and verifies the answer
+	Fire(context.Context, *ShootRequest) (*VerifyResponse, error)
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	// register the opponent server, answered with the new status
+	SetPeer(context.Context, *SetPeerRequest) (*StatusResponse, error)
+	mustEmbedUnimplementedBattleshipServer()
+}
+
+// UnimplementedBattleshipServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBattleshipServer struct{}
+
+func (UnimplementedBattleshipServer) Init(context.Context, *InitRequest) (*Board, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Init not implemented")
+}
+func (UnimplementedBattleshipServer) Commit(context.Context, *CommitRequest) (*CommitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedBattleshipServer) Shoot(context.Context, *ShootRequest) (*ShootResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shoot not implemented")
+}
+func (UnimplementedBattleshipServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedBattleshipServer) Fire(context.Context, *ShootRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fire not implemented")
+}
+func (UnimplementedBattleshipServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedBattleshipServer) SetPeer(context.Context, *SetPeerRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPeer not implemented")
+}
+func (UnimplementedBattleshipServer) mustEmbedUnimplementedBattleshipServer() {}
+func (UnimplementedBattleshipServer) testEmbeddedByValue()                    {}
+
+// UnsafeBattleshipServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BattleshipServer will
+// result in compilation errors.
+type UnsafeBattleshipServer interface {
+	mustEmbedUnimplementedBattleshipServer()
+}
+
+func RegisterBattleshipServer(s grpc.ServiceRegistrar, srv BattleshipServer) {
+	// If the following call pancis, it indicates UnimplementedBattleshipServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Battleship_ServiceDesc, srv)
+}
+
+func _Battleship_Init_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).Init(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_Init_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).Init(ctx, req.(*InitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Battleship_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_Commit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).Commit(ctx, req.(*CommitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Battleship_Shoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).Shoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_Shoot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).Shoot(ctx, req.(*ShootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Battleship_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Battleship_Fire_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).Fire(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_Fire_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).Fire(ctx, req.(*ShootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Battleship_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Battleship_SetPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BattleshipServer).SetPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Battleship_SetPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BattleshipServer).SetPeer(ctx, req.(*SetPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Battleship_ServiceDesc is the grpc.ServiceDesc for Battleship service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Battleship_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "battleship.v1.Battleship",
+	HandlerType: (*BattleshipServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Init",
+			Handler:    _Battleship_Init_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _Battleship_Commit_Handler,
+		},
+		{
+			MethodName: "Shoot",
+			Handler:    _Battleship_Shoot_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _Battleship_Verify_Handler,
+		},
+		{
+			MethodName: "Fire",
+			Handler:    _Battleship_Fire_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Battleship_Status_Handler,
+		},
+		{
+			MethodName: "SetPeer",
+			Handler:    _Battleship_SetPeer_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "battleship/v1/battleship.proto",
+}
@@ -0,0 +1,6 @@
+// Package battleshipv1 holds the generated protobuf messages and gRPC client
+// for the Battleship service in proto/battleship/v1, served by
+// `battleship serve --grpc-addr`.
+package battleshipv1
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=battleship-zk --go-grpc_out=../.. --go-grpc_opt=module=battleship-zk battleship/v1/battleship.proto
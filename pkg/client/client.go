@@ -0,0 +1,237 @@
+// Package client drives a battleship server over its /v1 HTTP API.
+//
+// Each player runs a server. The attacker sends Shoot to the defender's
+// server, then Verify with the answer to its own server. Fire does both.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"battleship-zk/pkg/api"
+)
+
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Retry   RetryPolicy
+}
+
+// RetryPolicy retries transient failures: connection errors and 502/503/504.
+// Commit, Shoot and Verify change game state, so they are only retried when
+// the server did not act on them (connection refused or 503).
+type RetryPolicy struct {
+	Attempts int           // total tries, 1 disables retry
+	Backoff  time.Duration // doubled after every try
+}
+
+var DefaultRetry = RetryPolicy{Attempts: 3, Backoff: 200 * time.Millisecond}
+
+type Option func(*Client)
+
+func WithHTTPClient(hc *http.Client) Option { return func(c *Client) { c.HTTP = hc } }
+
+func WithRetry(p RetryPolicy) Option { return func(c *Client) { c.Retry = p } }
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		// shoot proves a full Groth16 circuit, keep room for a slow machine
+		HTTP:  &http.Client{Timeout: 2 * time.Minute},
+		Retry: DefaultRetry,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Error is a non-2xx answer, Body is the server's error envelope. Code is
+// empty if the body could not be read as one.
+type Error struct {
+	StatusCode int
+	Body       api.Error
+}
+
+func (e *Error) Error() string {
+	if e.Body.Message == "" {
+		return fmt.Sprintf("battleship: HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("battleship: HTTP %d: %s (%s)", e.StatusCode, e.Body.Message, e.Body.Code)
+}
+
+// Code returns the api.ErrorCode of err, or "" if it is not an *Error
+func Code(err error) api.ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Body.Code
+	}
+	return ""
+}
+
+// Init returns a random valid board, it is not committed
+func (c *Client) Init(ctx context.Context, req api.InitRequest) (*api.Board, error) {
+	var b api.Board
+	if err := c.do(ctx, http.MethodPost, "/v1/init", req, &b, true); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (c *Client) Commit(ctx context.Context, req api.CommitRequest) (*api.CommitResponse, error) {
+	var out api.CommitResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/commit", req, &out, false); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Shoot fires at (row, col) on this client's server, which has to be the defender
+func (c *Client) Shoot(ctx context.Context, row, col int) (*api.ShootResponse, error) {
+	var out api.ShootResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/shoot", api.ShootRequest{Row: row, Col: col}, &out, false); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) Verify(ctx context.Context, req api.VerifyRequest) (*api.VerifyResponse, error) {
+	var out api.VerifyResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/verify", req, &out, false); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FireAtPeer is one attack made by this client's server: it shoots at its
+// registered peer and verifies the answer itself. Unlike Fire this works
+// when the peer only talks to the other server (mTLS).
+func (c *Client) FireAtPeer(ctx context.Context, row, col int) (*api.VerifyResponse, error) {
+	var out api.VerifyResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/fire", api.ShootRequest{Row: row, Col: col}, &out, false); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) Status(ctx context.Context) (*api.Status, error) {
+	var out api.Status
+	if err := c.do(ctx, http.MethodGet, "/v1/status", nil, &out, true); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetPeer registers the opponent's server and returns the updated status
+func (c *Client) SetPeer(ctx context.Context, peer api.Peer) (*api.Status, error) {
+	var out api.Status
+	if err := c.do(ctx, http.MethodPut, "/v1/peer", peer, &out, true); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Fire is one attack: shoot at the defender's server and verify the answer
+// on the attacker's, which moves both turns along
+func Fire(ctx context.Context, attacker, defender *Client, row, col int) (*api.VerifyResponse, error) {
+	shot, err := defender.Shoot(ctx, row, col)
+	if err != nil {
+		return nil, err
+	}
+	return attacker.Verify(ctx, api.VerifyRequest{
+		RootHex: shot.RootHex,
+		Payload: shot.Payload,
+		VKB64:   shot.VKB64,
+	})
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any, idempotent bool) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	attempts := max(c.Retry.Attempts, 1)
+	backoff := c.Retry.Backoff
+	var err error
+	for try := 0; try < attempts; try++ {
+		if try > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		var retry bool
+		retry, err = c.once(ctx, method, path, body, out, idempotent)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// once sends a single request, retry reports whether err is worth another try
+func (c *Client) once(ctx context.Context, method, path string, body []byte, out any, idempotent bool) (retry bool, err error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, rd)
+	if err != nil {
+		return false, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, err
+		}
+		return idempotent || notSent(err), err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return idempotent, err
+	}
+	if resp.StatusCode/100 != 2 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(data, &apiErr.Body)
+		switch resp.StatusCode {
+		case http.StatusServiceUnavailable:
+			return true, apiErr
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return idempotent, apiErr
+		}
+		return false, apiErr
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("battleship: decode %s: %w", path, err)
+	}
+	return false, nil
+}
+
+// connection refused and friends, the server never saw the request
+func notSent(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
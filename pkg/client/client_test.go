@@ -0,0 +1,202 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+var fastRetry = client.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+// counting passes requests on to http.DefaultTransport and counts them
+type counting struct{ n atomic.Int32 }
+
+func (c *counting) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newClient(url string) (*client.Client, *counting) {
+	rt := &counting{}
+	return client.New(url, client.WithRetry(fastRetry), client.WithHTTPClient(&http.Client{Transport: rt})), rt
+}
+
+// calls are the client methods under test, by name
+var calls = map[string]func(context.Context, *client.Client) error{
+	"status": func(ctx context.Context, c *client.Client) error { _, err := c.Status(ctx); return err },
+	"commit": func(ctx context.Context, c *client.Client) error {
+		_, err := c.Commit(ctx, api.CommitRequest{})
+		return err
+	},
+	"shoot": func(ctx context.Context, c *client.Client) error { _, err := c.Shoot(ctx, 1, 2); return err },
+}
+
+func TestRetryOnStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     string
+		statuses []int // answers in order, the last repeats
+		tries    int32
+		want     int // status of the returned error, 0 for success
+	}{
+		{"get 502 then ok", "status", []int{502, 200}, 2, 0},
+		{"get 503 then ok", "status", []int{503, 200}, 2, 0},
+		{"get 504 then ok", "status", []int{504, 200}, 2, 0},
+		{"get gives up", "status", []int{502}, 3, 502},
+		{"get 400 is final", "status", []int{400}, 1, 400},
+		{"commit 503 then ok", "commit", []int{503, 200}, 2, 0},
+		{"commit 502 is final", "commit", []int{502}, 1, 502},
+		{"commit 504 is final", "commit", []int{504}, 1, 504},
+		{"shoot 503 gives up", "shoot", []int{503}, 3, 503},
+		{"shoot 502 is final", "shoot", []int{502, 200}, 1, 502},
+		{"shoot 409 is final", "shoot", []int{409, 200}, 1, 409},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var n atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := min(int(n.Add(1))-1, len(tc.statuses)-1)
+				w.WriteHeader(tc.statuses[i])
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+			c, rt := newClient(srv.URL)
+
+			err := calls[tc.call](context.Background(), c)
+			var ce *client.Error
+			switch {
+			case tc.want == 0 && err != nil:
+				t.Errorf("err = %v, want success", err)
+			case tc.want != 0 && (!errors.As(err, &ce) || ce.StatusCode != tc.want):
+				t.Errorf("err = %v, want HTTP %d", err, tc.want)
+			}
+			if got := rt.n.Load(); got != tc.tries {
+				t.Errorf("%d tries, want %d", got, tc.tries)
+			}
+		})
+	}
+}
+
+// a request the server never got is retried whatever the method, one it
+// may have acted on only if it is idempotent
+func TestRetryOnConnErrors(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + lis.Addr().String()
+	lis.Close()
+
+	// reads the request, then hangs up without an answer
+	dropped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer dropped.Close()
+
+	tests := []struct {
+		name  string
+		url   string
+		call  string
+		tries int32
+	}{
+		{"get refused", refused, "status", 3},
+		{"commit refused", refused, "commit", 3},
+		{"shoot refused", refused, "shoot", 3},
+		{"get dropped", dropped.URL, "status", 3},
+		{"commit dropped", dropped.URL, "commit", 1},
+		{"shoot dropped", dropped.URL, "shoot", 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, rt := newClient(tc.url)
+			if err := calls[tc.call](context.Background(), c); err == nil {
+				t.Fatal("no error")
+			}
+			if got := rt.n.Load(); got != tc.tries {
+				t.Errorf("%d tries, want %d", got, tc.tries)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	rt := &counting{}
+	c := client.New(srv.URL, client.WithRetry(client.RetryPolicy{Attempts: 5, Backoff: time.Hour}), client.WithHTTPClient(&http.Client{Transport: rt}))
+
+	done := make(chan error, 1)
+	go func() { _, err := c.Status(ctx); done <- err }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("still backing off after the context was cancelled")
+	}
+	if got := rt.n.Load(); got != 1 {
+		t.Errorf("%d tries, want 1", got)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   api.Error
+		msg    string
+	}{
+		{"turn", 409, `{"code":"not_your_turn","error":"not allowed","turn":{"myTurn":"me","ready":true,"decided":true}}`,
+			api.Error{Code: api.CodeNotYourTurn, Message: "not allowed", Turn: &api.TurnState{MyTurn: "me", Ready: true, Decided: true}},
+			"battleship: HTTP 409: not allowed (not_your_turn)"},
+		{"game", 409, `{"code":"game_over","error":"game is over","game":{"hitsTaken":17,"over":true,"winner":"opponent"}}`,
+			api.Error{Code: api.CodeGameOver, Message: "game is over", Game: &api.GameState{HitsTaken: 17, Over: true, Winner: "opponent"}},
+			"battleship: HTTP 409: game is over (game_over)"},
+		{"not json", 500, `oops`, api.Error{}, "battleship: HTTP 500"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+			c, _ := newClient(srv.URL)
+
+			_, err := c.Shoot(context.Background(), 0, 0)
+			var ce *client.Error
+			if !errors.As(err, &ce) {
+				t.Fatalf("err = %v (%T), want *client.Error", err, err)
+			}
+			if ce.StatusCode != tc.status || ce.Body.Code != tc.want.Code || ce.Body.Message != tc.want.Message {
+				t.Errorf("error %d %+v, want %d %+v", ce.StatusCode, ce.Body, tc.status, tc.want)
+			}
+			if (ce.Body.Turn == nil) != (tc.want.Turn == nil) || (ce.Body.Turn != nil && *ce.Body.Turn != *tc.want.Turn) {
+				t.Errorf("turn %+v, want %+v", ce.Body.Turn, tc.want.Turn)
+			}
+			if (ce.Body.Game == nil) != (tc.want.Game == nil) || (ce.Body.Game != nil && *ce.Body.Game != *tc.want.Game) {
+				t.Errorf("game %+v, want %+v", ce.Body.Game, tc.want.Game)
+			}
+			if client.Code(err) != tc.want.Code || err.Error() != tc.msg {
+				t.Errorf("Code = %q, Error() = %q", client.Code(err), err.Error())
+			}
+		})
+	}
+}
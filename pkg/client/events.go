@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"battleship-zk/pkg/api"
+)
+
+// Events opens the /v1/ws push channel. The first event is always a full
+// status. The channel is closed when ctx is done or the connection drops,
+// reconnecting gives a fresh status.
+func (c *Client) Events(ctx context.Context) (<-chan api.Event, error) {
+	url := "ws" + strings.TrimPrefix(c.BaseURL, "http") + "/v1/ws"
+	conn, _, err := c.dialer().DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan api.Event, 16)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	go func() {
+		defer close(out)
+		defer close(done)
+		defer conn.Close()
+		for {
+			var ev api.Event
+			if err := conn.ReadJSON(&ev); err != nil {
+				return
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// dialer is websocket.DefaultDialer with the proxy and TLS settings of
+// c.HTTP, so the stream reaches a server the REST calls reach
+func (c *Client) dialer() *websocket.Dialer {
+	d := *websocket.DefaultDialer
+	tr, ok := c.HTTP.Transport.(*http.Transport)
+	if c.HTTP.Transport == nil {
+		tr, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if ok {
+		d.Proxy = tr.Proxy
+		if tr.TLSClientConfig != nil {
+			d.TLSClientConfig = tr.TLSClientConfig.Clone()
+		}
+	}
+	return &d
+}
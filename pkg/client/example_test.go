@@ -0,0 +1,103 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/consensys/gnark/logger"
+
+	"battleship-zk/internal/server"
+	"battleship-zk/pkg/api"
+	"battleship-zk/pkg/client"
+)
+
+// Two in-process servers commit the same seeded board and play a full
+// game. Fire asks the defender's server for a proof and has the attacker's
+// server verify it. Both sides fire at the ship cells in order, so the
+// first attacker sinks the last ship one shot ahead.
+func ExampleFire() {
+	logger.Disable()
+	ctx := context.Background()
+	keys, err := os.MkdirTemp("", "battleship-keys")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(keys)
+
+	start := func() *httptest.Server {
+		mux := http.NewServeMux()
+		server.New(keys, "").Routes(mux)
+		return httptest.NewServer(mux)
+	}
+	srvA, srvB := start(), start()
+	defer srvA.Close()
+	defer srvB.Close()
+	a, b := client.New(srvA.URL), client.New(srvB.URL)
+
+	seed := uint64(7)
+	var board *api.Board
+	for _, c := range []*client.Client{a, b} {
+		if board, err = c.Init(ctx, api.InitRequest{Seed: &seed}); err != nil {
+			log.Fatal(err)
+		}
+		if _, err := c.Commit(ctx, api.CommitRequest{Board: *board}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if _, err := a.SetPeer(ctx, api.Peer{BaseURL: srvB.URL}); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := b.SetPeer(ctx, api.Peer{BaseURL: srvA.URL}); err != nil {
+		log.Fatal(err)
+	}
+
+	var ships [][2]int
+	for r := 0; r < 10; r++ {
+		for c := 0; c < 10; c++ {
+			if board.Cells[r][c] == 1 {
+				ships = append(ships, [2]int{r, c})
+			}
+		}
+	}
+	next := map[*client.Client]int{}
+	var first *client.Client
+	for {
+		st, err := a.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if st.Game.Over {
+			break
+		}
+		attacker, defender := a, b
+		if st.Turn.MyTurn != "me" {
+			attacker, defender = b, a
+		}
+		if first == nil {
+			first = attacker
+		}
+		cell := ships[next[attacker]]
+		next[attacker]++
+		res, err := client.Fire(ctx, attacker, defender, cell[0], cell[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !res.Valid || res.Hit != 1 {
+			log.Fatalf("shot at %v: valid %v, hit %d", cell, res.Valid, res.Hit)
+		}
+	}
+
+	st, err := first.Status(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("shots fired:", next[a]+next[b])
+	fmt.Println("first attacker:", st.Game.Winner, "won, hits dealt", st.Game.HitsDealt, "taken", st.Game.HitsTaken)
+	// Output:
+	// shots fired: 33
+	// first attacker: me won, hits dealt 17 taken 16
+}
@@ -44,14 +44,12 @@ async function readStatus() {
   }
 }
 
-async function refreshTurn() {
-  const s = await readStatus();
-  if (!s || !s.turn) {
+function applyTurn(t) {
+  if (!t) {
     oppBoardEl.style.pointerEvents = 'none';
     oppBoardEl.style.opacity = '0.5';
     return;
   }
-  const t = s.turn;
   const canClick = t.decided === true && t.ready === true && t.myTurn === 'me';
   statusEl.textContent = canClick ? "Your turn" :
                          (t && t.decided ? "Opponent’s turn" : "Deciding turns…");
@@ -59,10 +57,13 @@ async function refreshTurn() {
   oppBoardEl.style.opacity = canClick ? '1' : '0.5';
 }
 
-async function refreshGameState() {
+async function refreshTurn() {
   const s = await readStatus();
-  if (!s || !s.game) return false;
-  const g = s.game;
+  applyTurn(s && s.turn);
+}
+
+function applyGame(g) {
+  if (!g) return false;
   if (g.over) {
     oppBoardEl.style.pointerEvents = 'none';
     oppBoardEl.style.opacity = '0.5';
@@ -75,10 +76,13 @@ async function refreshGameState() {
   return false;
 }
 
-async function pollIncomingDefense() {
+async function refreshGameState() {
   const s = await readStatus();
-  if (!s || !s.defenseLast) return;
-  const ev = s.defenseLast;
+  return applyGame(s && s.game);
+}
+
+function applyDefense(ev) {
+  if (!ev) return;
   if (!ev.n || ev.n <= lastIncomingN) return;
   lastIncomingN = ev.n;
   const k = `${ev.row},${ev.col}`;
@@ -86,6 +90,36 @@ async function pollIncomingDefense() {
   drawBoard(yourBoardEl, false, true);
 }
 
+// server push on /v1/ws replaces polling /v1/status, on a drop we reconnect
+// and the first message is a full status again
+function connectEvents() {
+  const proto = location.protocol === 'https:' ? 'wss' : 'ws';
+  const ws = new WebSocket(`${proto}://${location.host}/v1/ws`);
+  ws.onmessage = (m) => {
+    let ev;
+    try { ev = JSON.parse(m.data); } catch { return; }
+    switch (ev.type) {
+      case 'status':
+        applyTurn(ev.status.turn);
+        applyDefense(ev.status.defenseLast);
+        applyGame(ev.status.game);
+        break;
+      case 'turn':
+        applyTurn(ev.turn);
+        break;
+      case 'defense':
+        applyDefense(ev.shot);
+        applyGame(ev.game);
+        break;
+      case 'verified':
+      case 'game_over':
+        applyGame(ev.game);
+        break;
+    }
+  };
+  ws.onclose = () => setTimeout(connectEvents, 1000);
+}
+
 function drawBoard(container, clickable, showShips = false) {
   container.innerHTML = "";
   for (let r = 0; r < 10; r++) {
@@ -137,32 +171,13 @@ async function onStartClick() {
     yourBoard = await postJSON('/v1/init', {});
     await postJSON('/v1/commit', { board: yourBoard });
 
-    // remove this route later and combine it into /status
-    // unused variable????
-    let s1 = await putJSON('/v1/peer', { baseUrl: opponent.baseUrl });
-
-    try {
-      const oppStatus = await getJSON(`${oppUrl}/v1/status`);
-
-      if (!oppStatus || !oppStatus.startedAt) {
-        setStatus("Opponent server is not ready or returned invalid data.", false);
-        return;
-      }
-
-      if (oppStatus && (oppStatus.myRootHex || oppStatus.vkB64)) {
-        opponent.rootHex = oppStatus.myRootHex || null;
-        opponent.vkB64   = oppStatus.vkB64   || null;
-
-        s1 = await putJSON('/v1/peer', {
-          baseUrl: opponent.baseUrl,
-          rootHex: opponent.rootHex || "",
-          vkB64:   opponent.vkB64   || ""
-        });
-      }
-    } catch {
-        setStatus("Opponent is offline or URL is incorrect.", false);
-        return;
+    // our server asks the opponent's for its root and vk itself
+    const s1 = await putJSON('/v1/peer', { baseUrl: opponent.baseUrl });
+    if (!s1.peerHealth || !s1.peerHealth.online) {
+      setStatus("Opponent is offline or URL is incorrect.", false);
+      return;
     }
+    opponent.rootHex = s1.oppRootHex || null;
 
     drawBoard(yourBoardEl, false, true);
     drawBoard(oppBoardEl, true, false);
@@ -188,17 +203,9 @@ async function onShootCell(e) {
       return;
     }
 
-    const shot = await postJSON(`${opponent.baseUrl}/v1/shoot`, { row: r, col: c });
-
-    const rootHex = shot.rootHex || opponent.rootHex;
-    const vkB64   = shot.vkB64   || opponent.vkB64;
-    if (!rootHex || !vkB64) { setStatus("Missing opponent root/vk.", false); return; }
-
-    const verify = await postJSON('/v1/verify', {
-      rootHex: rootHex,
-      payload: shot.payload || shot.Payload || {},
-      vkB64:   vkB64
-    });
+    // our server shoots at the opponent's and verifies the proof, so this
+    // also works when the servers only talk to each other over mTLS
+    const verify = await postJSON('/v1/fire', { row: r, col: c });
 
     const hit = verify && (verify.Hit === 1 || verify.hit === 1);
     shotState[gridKey(r,c)] = hit ? "hit" : "miss";
@@ -224,9 +231,5 @@ startBtn.addEventListener("click", onStartClick);
 window.addEventListener('DOMContentLoaded', async () => {
   drawBoard(yourBoardEl, false, true);
   drawBoard(oppBoardEl, true, false);
-  await refreshTurn();
-  setInterval(refreshTurn, 1000);
-  setInterval(pollIncomingDefense, 1200);
-  await refreshGameState();
-  setInterval(refreshGameState, 1000);
+  connectEvents();
 });